@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"raphaelcoutu/traduction-poso/poso"
+	"raphaelcoutu/traduction-poso/translate"
+)
+
+const usage = `Utilisation : traduction-poso <commande> [options]
+
+Commandes :
+  parse [options] <posologie>...   analyse les posologies passées en arguments
+  batch [options]                  analyse un fichier (ou l'entrée standard), une posologie par ligne
+  stats [options]                  statistiques de couverture d'un fichier de posologies
+  unparsed [options]               regroupe par gabarit les posologies non analysées
+  translate [options]              traduit un fichier de posologies (EN→FR ou FR→EN)
+
+Formats d'entrée de batch (-input) : text (une posologie par ligne), csv, tsv (avec en-tête,
+-sig-column, -id-column et -columns)
+
+Formats de sortie (-format) : json, ndjson (une posologie JSON par ligne), csv (tous les champs,
+avec en-tête), text, fr (posologie normalisée), en (posologie anglaise), explain (règles qui ont
+produit chaque champ; -explain les ajoute aussi au JSON sous "trace")
+
+Codes de sortie : 0 succès, 1 lignes en échec ou erreur, 2 utilisation invalide
+`
+
+// Codes de sortie
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// run exécute la commande args[0] et retourne le code de sortie
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "parse":
+		err = runParse(args[1:], stdout, stderr)
+	case "batch":
+		err = runBatch(args[1:], stdin, stdout, stderr)
+	case "stats":
+		err = runStats(args[1:], stdin, stdout, stderr)
+	case "unparsed":
+		err = runUnparsed(args[1:], stdin, stdout, stderr)
+	case "translate":
+		err = runTranslate(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "commande inconnue : %s\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	default:
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+}
+
+// usageError signale une option ou un argument invalide
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// parseFlags analyse les options et signale les options invalides comme une
+// erreur d'utilisation
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{err.Error()}
+	}
+	return err
+}
+
+// Options communes à parse, batch et stats
+type parseOptions struct {
+	format  string
+	catalog string
+	strict  bool
+	explain bool
+	workers int
+}
+
+func (o *parseOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.catalog, "catalog", "", "catalogue des fréquences (CSV ou JSON, catalog.csv par défaut s'il existe)")
+	flags.BoolVar(&o.strict, "strict", false, "considérer en échec les lignes sans dose, fréquence ni étapes")
+	flags.BoolVar(&o.explain, "explain", false, "ajouter à chaque posologie les règles qui ont produit ses champs (trace)")
+	flags.IntVar(&o.workers, "workers", runtime.NumCPU(), "nombre d'analyses en parallèle")
+}
+
+func (o *parseOptions) registerFormat(flags *flag.FlagSet) {
+	flags.StringVar(&o.format, "format", "json", "format de sortie (json, ndjson, csv, text, fr, en ou explain)")
+}
+
+// runParse analyse les posologies passées en arguments
+// (ex: traduction-poso parse -format fr "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR").
+func runParse(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	options := parseOptions{}
+	options.register(flags)
+	options.registerFormat(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usageError{"parse : aucune posologie à analyser"}
+	}
+
+	newWriter, err := formatWriter(options.format)
+	if err != nil {
+		return err
+	}
+	report, err := newErrorReport(nil, stderr)
+	if err != nil {
+		return err
+	}
+	return parseAll(&argsReader{sigs: flags.Args()}, options, newWriter(stdout), report)
+}
+
+// Options communes à batch et stats
+type batchOptions struct {
+	parseOptions
+	input  inputOptions
+	in     string
+	out    string
+	errors string
+}
+
+func (o *batchOptions) register(flags *flag.FlagSet) {
+	o.parseOptions.register(flags)
+	o.input.register(flags)
+	flags.StringVar(&o.in, "in", "-", "fichier de posologies (- pour l'entrée standard)")
+	flags.StringVar(&o.out, "out", "-", "fichier de sortie (- pour la sortie standard)")
+	flags.StringVar(&o.errors, "errors", "", "rapport CSV des lignes en échec (stderr par défaut)")
+}
+
+// runBatch analyse un fichier de posologies, une par ligne. Les lignes en
+// échec n'interrompent pas le lot : elles sont signalées dans stderr ou dans le
+// rapport -errors, suivies d'un résumé
+// (ex: traduction-poso batch -in in_sample.txt -out out.json -errors erreurs.csv).
+func runBatch(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	options := batchOptions{}
+	options.register(flags)
+	options.registerFormat(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	newWriter, err := formatWriter(options.format)
+	if err != nil {
+		return err
+	}
+	return batch(options, newWriter, stdin, stdout, stderr)
+}
+
+// runStats analyse un fichier de posologies comme batch, mais écrit les
+// statistiques de couverture du lot au lieu des posologies
+// (ex: traduction-poso stats -in in_sample.txt -top 30).
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	options := batchOptions{}
+	options.register(flags)
+	flags.StringVar(&options.format, "format", "text", "format des statistiques (text ou json)")
+	top := flags.Int("top", 20, "nombre de posologies non analysées les plus fréquentes (0 pour toutes)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if options.format != "text" && options.format != "json" {
+		return usageError{fmt.Sprintf("format invalide : %s", options.format)}
+	}
+	if *top < 0 {
+		return usageError{fmt.Sprintf("nombre de posologies invalide : %d", *top)}
+	}
+
+	return batch(options, func(w io.Writer) DosageWriter {
+		return NewStatsWriter(w, options.format, *top)
+	}, stdin, stdout, stderr)
+}
+
+// runUnparsed analyse un fichier de posologies comme batch et regroupe par
+// gabarit celles dont le champ -field n'a pu être analysé
+// (ex: traduction-poso unparsed -in in_sample.txt -field frequency -top 50).
+func runUnparsed(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("unparsed", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	options := batchOptions{}
+	options.register(flags)
+	flags.StringVar(&options.format, "format", "text", "format du rapport (text ou json)")
+	field := flags.String("field", "frequency", "champ non analysé (frequency, dose, route ou all)")
+	top := flags.Int("top", 30, "nombre de gabarits et de n-grammes les plus fréquents (0 pour tous)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if options.format != "text" && options.format != "json" {
+		return usageError{fmt.Sprintf("format invalide : %s", options.format)}
+	}
+	if _, ok := unparsedFields[*field]; !ok {
+		return usageError{fmt.Sprintf("champ invalide : %s", *field)}
+	}
+	if *top < 0 {
+		return usageError{fmt.Sprintf("nombre de gabarits invalide : %d", *top)}
+	}
+
+	return batch(options, func(w io.Writer) DosageWriter {
+		return NewUnparsedWriter(w, options.format, *field, *top)
+	}, stdin, stdout, stderr)
+}
+
+// batch analyse le fichier options.in et écrit les posologies dans
+// options.out à l'aide du DosageWriter retourné par newWriter
+func batch(options batchOptions, newWriter func(io.Writer) DosageWriter, stdin io.Reader, stdout, stderr io.Writer) error {
+	r, closeInput, err := openInput(options.in, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	w, closeOutput, err := createOutput(options.out, stdout)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	reader, err := newRecordReader(r, options.in, options.input)
+	if err != nil {
+		return err
+	}
+
+	var errorsOutput io.Writer
+	if options.errors != "" {
+		file, err := os.Create(options.errors)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		errorsOutput = file
+	}
+	report, err := newErrorReport(errorsOutput, stderr)
+	if err != nil {
+		return err
+	}
+
+	err = parseAll(reader, options.parseOptions, newWriter(w), report)
+	fmt.Fprintln(stderr, report.summary())
+	return err
+}
+
+// parseAll analyse les posologies lues par reader, écrit chacune avec writer
+// dès qu'elle est analysée et signale les lignes en échec dans report.
+func parseAll(reader recordReader, options parseOptions, writer DosageWriter, report *errorReport) error {
+	if options.workers < 1 {
+		return usageError{fmt.Sprintf("nombre d'analyses en parallèle invalide : %d", options.workers)}
+	}
+	parser, err := newParser(options.catalog)
+	if err != nil {
+		return err
+	}
+	parser.Explain = options.explain || options.format == "explain"
+
+	// Les posologies sont émises dans l'ordre de lecture : les identifiants
+	// et colonnes d'origine attendent dans une file
+	var queue []record
+	var readErr error
+	next := func() (string, bool) {
+		for {
+			rec, err := reader.Read()
+			var parseErr *poso.ParseError
+			if errors.As(err, &parseErr) {
+				// Enregistrement illisible : le lot se poursuit
+				report.total++
+				if readErr = report.add(parseErr, poso.Dosage{}); readErr != nil {
+					return "", false
+				}
+				continue
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return "", false
+			}
+			queue = append(queue, rec)
+			return rec.text, true
+		}
+	}
+
+	err = parser.ParseOrdered(options.workers, next, func(dosage poso.Dosage, err error) error {
+		rec := queue[0]
+		queue = queue[1:]
+		dosage.SourceId, dosage.Columns = rec.sourceId, rec.columns
+
+		report.total++
+		var parseErr *poso.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			parseErr = &poso.ParseError{Message: err.Error()}
+		}
+		if parseErr == nil && options.strict && isUnparsed(dosage) {
+			parseErr = &poso.ParseError{Stage: poso.StageValidation, Message: "posologie non analysée : " + dosage.Text}
+		}
+		if parseErr != nil {
+			parseErr.Line = rec.line
+			if err := report.add(parseErr, dosage); err != nil {
+				return err
+			}
+		}
+
+		if dosage.Frequency != "" && dosage.FrequencyId == 0 {
+			catalogErr := &poso.ParseError{Line: rec.line, Stage: poso.StageCatalog, Message: "fréquence absente du catalogue : " + dosage.Frequency}
+			if err := report.add(catalogErr, dosage); err != nil {
+				return err
+			}
+		}
+
+		return writer.Write(dosage)
+	})
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := report.flush(); err != nil {
+		return err
+	}
+
+	return report.err()
+}
+
+// isUnparsed indique si la posologie n'a ni dose, ni fréquence, ni étapes
+func isUnparsed(dosage poso.Dosage) bool {
+	return dosage.Dose == "" && dosage.Frequency == "" && len(dosage.Steps) == 0
+}
+
+// formatWriter retourne le constructeur du DosageWriter du format demandé
+func formatWriter(format string) (func(io.Writer) DosageWriter, error) {
+	switch format {
+	case "json":
+		return func(w io.Writer) DosageWriter { return NewJsonWriter(w) }, nil
+	case "ndjson":
+		return func(w io.Writer) DosageWriter { return NewNdjsonWriter(w) }, nil
+	case "csv":
+		return func(w io.Writer) DosageWriter { return NewCsvWriter(w) }, nil
+	case "text":
+		return func(w io.Writer) DosageWriter { return NewTextWriter(w) }, nil
+	case "fr":
+		return func(w io.Writer) DosageWriter { return NewLineWriter(w, poso.ToFrench) }, nil
+	case "en":
+		return func(w io.Writer) DosageWriter { return NewLineWriter(w, poso.ToEnglish) }, nil
+	case "explain":
+		return func(w io.Writer) DosageWriter { return NewExplainWriter(w) }, nil
+	}
+	return nil, usageError{fmt.Sprintf("format invalide : %s", format)}
+}
+
+// newParser retourne un Parser utilisant le catalogue des fréquences path.
+// Sans chemin, catalog.csv est chargé s'il existe dans le répertoire courant.
+func newParser(path string) (*poso.Parser, error) {
+	parser := poso.NewParser()
+	if path == "" {
+		if _, err := os.Stat("catalog.csv"); err != nil {
+			return parser, nil
+		}
+		path = "catalog.csv"
+	}
+
+	catalog, err := poso.LoadFrequencyCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	parser.Catalog = catalog
+	return parser, nil
+}
+
+// runTranslate traduit un fichier de posologies anglaises en français, ou
+// françaises en anglais avec -to en
+// (ex: traduction-poso translate -to en -in in_sample.txt -out out_en.txt).
+func runTranslate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("translate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	to := flags.String("to", "fr", "langue cible (fr ou en)")
+	in := flags.String("in", "-", "fichier de posologies à traduire (- pour l'entrée standard)")
+	out := flags.String("out", "-", "fichier des posologies traduites (- pour la sortie standard)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *to != "fr" && *to != "en" {
+		return usageError{fmt.Sprintf("langue cible invalide : %s", *to)}
+	}
+
+	r, closeInput, err := openInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	w, closeOutput, err := createOutput(*out, stdout)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	if *to == "fr" {
+		return translate.TransformAll(r, w)
+	}
+	return toEnglishAll(r, w)
+}
+
+// toEnglishAll analyse chaque posologie française de r et écrit sa traduction
+// anglaise dans w (ligne vide si la posologie n'a pu être lue ou analysée).
+// Comme pour batch, une posologie entre guillemets peut s'étendre sur
+// plusieurs lignes.
+func toEnglishAll(r io.Reader, w io.Writer) error {
+	writer := bufio.NewWriter(w)
+
+	reader, err := newRecordReader(r, "-", inputOptions{format: "text"})
+	if err != nil {
+		return err
+	}
+
+	parser := poso.NewParser()
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *poso.ParseError
+		if errors.As(err, &parseErr) {
+			if _, err := writer.WriteString("\n"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		dosage, err := parser.Parse(rec.text)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.WriteString(poso.ToEnglish(dosage) + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// openInput ouvre le fichier path, ou retourne stdin si path vaut "-"
+func openInput(path string, stdin io.Reader) (io.Reader, func() error, error) {
+	if path == "-" {
+		return stdin, func() error { return nil }, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// createOutput crée le fichier path, ou retourne stdout si path vaut "-"
+func createOutput(path string, stdout io.Writer) (io.Writer, func() error, error) {
+	if path == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
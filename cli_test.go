@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		stdin    string
+		expected string
+		exitCode int
+	}{
+		{
+			args:     []string{"parse", "-format", "fr", "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR"},
+			expected: "Prendre 1 comprimé par la bouche 2 fois par jour\n",
+			exitCode: exitOK,
+		},
+		{
+			args:     []string{"parse", "-format", "en", "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR", "PRENEZ 2 COMPRIMES AUX 6 HEURES SI BESOIN"},
+			expected: "Take 1 tablet by mouth twice a day\nTake 2 tablets by mouth every 6 hours as needed\n",
+			exitCode: exitOK,
+		},
+		{
+			args:     []string{"batch", "-format", "text"},
+			stdin:    "PRENDRE 2 COMPRIMES 1 FOIS PAR JOUR\n",
+			expected: "PRENDRE 2 COMPRIMES 1 FOIS PAR JOUR, 2, comprimé, 1 fois par jour\n",
+			exitCode: exitOK,
+		},
+		{
+			args:     []string{"batch", "-format", "fr", "-strict"},
+			stdin:    "SELON LES DIRECTIVES DU MEDECIN\nPRENDRE 1 COMPRIME\n",
+			expected: "\nPrendre 1 comprimé par la bouche\n",
+			exitCode: exitFailure,
+		},
+		{
+			args:     []string{"batch", "-format", "fr", "-workers", "3"},
+			stdin:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR\nPRENDRE 2 CAPSULES AU COUCHER\nINSTILLER 1 GOUTTE DANS CHAQUE OEIL 2 FOIS PAR JOUR\nPRENDRE 1 COMPRIME AUX 6 HEURES SI BESOIN\n",
+			expected: "Prendre 1 comprimé par la bouche 1 fois par jour\nPrendre 2 capsules par la bouche 1 fois par jour au coucher\nInstiller une goutte dans l'oeil atteint 2 fois par jour\nPrendre 1 comprimé par la bouche aux 6 heures au besoin\n",
+			exitCode: exitOK,
+		},
+		{
+			args:     []string{"batch", "-workers", "0"},
+			exitCode: exitUsage,
+		},
+		{
+			args:     []string{"translate"},
+			stdin:    "TAKE 1 TABLET BY MOUTH DAILY\n",
+			expected: "PRENDRE 1 COMPRIMÉ PAR LA BOUCHE 1 FOIS PAR JOUR\n",
+			exitCode: exitOK,
+		},
+		{
+			args:     []string{"translate", "-to", "en"},
+			stdin:    "\"PRENDRE 1 COMPRIME\nAU COUCHER\"\nPRENDRE 1 CAPSULE\n",
+			expected: "Take 1 tablet by mouth once a day at bedtime\nTake 1 capsule by mouth\n",
+			exitCode: exitOK,
+		},
+		{
+			// Guillemet non fermé : la ligne est en échec, les suivantes sont analysées
+			args:     []string{"batch", "-format", "fr"},
+			stdin:    "\"PRENDRE 2 COMPRIMES AU COUCHER\nPRENDRE 1 COMPRIME\nPRENDRE 1 CAPSULE\n",
+			expected: "Prendre 1 comprimé par la bouche\nPrendre une capsule par la bouche\n",
+			exitCode: exitFailure,
+		},
+		{
+			args:     []string{"parse"},
+			exitCode: exitUsage,
+		},
+		{
+			args:     []string{"parse", "-format", "xml", "PRENDRE 1 COMPRIME"},
+			exitCode: exitUsage,
+		},
+		{
+			args:     []string{"inconnue"},
+			exitCode: exitUsage,
+		},
+		{
+			args:     []string{},
+			exitCode: exitUsage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			exitCode := run(tc.args, strings.NewReader(tc.stdin), stdout, stderr)
+
+			if exitCode != tc.exitCode {
+				t.Errorf("Code de sortie\nI: %v\nE: %v\nA: %v\n%s", tc.args, tc.exitCode, exitCode, stderr)
+				return
+			}
+
+			if stdout.String() != tc.expected {
+				t.Errorf("Sortie\nI: %v\nE: %q\nA: %q", tc.args, tc.expected, stdout)
+				return
+			}
+		})
+	}
+}
+
+func TestRunBatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	out := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(in, []byte("PRENDRE 1 COMPRIME 1 FOIS PAR JOUR\nPRENDRE 2 CAPSULES 2 FOIS PAR JOUR\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if exitCode := run([]string{"batch", "-in", in, "-out", out}, nil, stdout, stderr); exitCode != exitOK {
+		t.Fatalf("Code de sortie %d\n%s", exitCode, stderr)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dosages []poso.Dosage
+	if err := json.Unmarshal(data, &dosages); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dosages) != 2 || dosages[1].Id != 2 || dosages[1].DoseUnit != "capsule" || dosages[1].Frequency != "2 fois par jour" {
+		t.Errorf("A: %+v", dosages)
+	}
+}
+
+func TestRunBatchCsv(t *testing.T) {
+	stdin := "No Rx;Médicament;Posologie\nRX-1;ATORVASTATINE;PRENDRE 1 COMPRIME AU COUCHER\nRX-2;AMOXICILLINE;PRENDRE 1 CAPSULE 3 FOIS PAR JOUR\n"
+	args := []string{"batch", "-input", "csv", "-sig-column", "posologie", "-id-column", "no rx", "-columns", "médicament", "-workers", "2"}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if exitCode := run(args, strings.NewReader(stdin), stdout, stderr); exitCode != exitOK {
+		t.Fatalf("Code de sortie %d\n%s", exitCode, stderr)
+	}
+
+	var dosages []poso.Dosage
+	if err := json.Unmarshal(stdout.Bytes(), &dosages); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dosages) != 2 || dosages[1].Id != 2 || dosages[1].SourceId != "RX-2" || dosages[1].Columns["Médicament"] != "AMOXICILLINE" || dosages[1].DoseUnit != "capsule" {
+		t.Errorf("A: %+v", dosages)
+	}
+}
+
+// Les lignes en échec sont écrites dans le rapport d'erreurs et le lot se poursuit
+func TestRunBatchErrors(t *testing.T) {
+	dir := t.TempDir()
+	errorsPath := filepath.Join(dir, "erreurs.csv")
+	stdin := "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR\n\"SELON LES DIRECTIVES\nDU MEDECIN\"\nPRENDRE 2 CAPSULES AU COUCHER\n"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	exitCode := run([]string{"batch", "-format", "fr", "-strict", "-errors", errorsPath}, strings.NewReader(stdin), stdout, stderr)
+	if exitCode != exitFailure {
+		t.Fatalf("Code de sortie %d\n%s", exitCode, stderr)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Sortie\nE: %v\nA: %q", 3, stdout)
+	}
+
+	if !strings.Contains(stderr.String(), "Résumé : 3 posologie(s), 2 analysée(s), 1 en échec (validation : 1)") {
+		t.Errorf("Résumé\nA: %s", stderr)
+	}
+
+	data, err := os.ReadFile(errorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "line,id,source_id,stage,message,text\n2,2,,validation,posologie non analysée : SELON LES DIRECTIVES DU MEDECIN,SELON LES DIRECTIVES DU MEDECIN\n"
+	if string(data) != expected {
+		t.Errorf("Rapport\nE: %q\nA: %q", expected, data)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	stdin := "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR\nSELON LES DIRECTIVES DU MEDECIN\nPRENDRE 1 CAPSULE 1 FOIS PAR JOUR\n"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if exitCode := run([]string{"stats", "-format", "json"}, strings.NewReader(stdin), stdout, stderr); exitCode != exitOK {
+		t.Fatalf("Code de sortie %d\n%s", exitCode, stderr)
+	}
+
+	var stats Stats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Total != 3 || stats.Fields[3].Field != "frequency" || stats.Fields[3].Populated != 2 || len(stats.Unparsed) != 1 || stats.Frequencies[0].Value != "1 fois par jour" {
+		t.Errorf("A: %+v", stats)
+	}
+}
+
+// -explain ajoute la trace des règles au JSON
+func TestRunExplain(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected int
+	}{
+		{args: []string{"parse", "-format", "ndjson", "PRENDRE 1 COMPRIME AU COUCHER"}, expected: 0},
+		{args: []string{"parse", "-format", "ndjson", "-explain", "PRENDRE 1 COMPRIME AU COUCHER"}, expected: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			if exitCode := run(tc.args, strings.NewReader(""), stdout, stderr); exitCode != exitOK {
+				t.Fatalf("E: %v\nA: %v\n%s", exitOK, exitCode, stderr)
+			}
+
+			var dosage poso.Dosage
+			if err := json.Unmarshal(stdout.Bytes(), &dosage); err != nil {
+				t.Fatal(err)
+			}
+			if len(dosage.Trace) != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %+v", tc.args, tc.expected, dosage.Trace)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+// errorReport collecte les erreurs par ligne d'un lot. Chacune est écrite dans
+// le rapport d'erreurs (CSV) s'il est demandé, sinon dans stderr; le lot se
+// poursuit et un résumé par étape est produit à la fin.
+type errorReport struct {
+	stderr   io.Writer
+	writer   *csv.Writer
+	total    int
+	failures int
+	stages   map[string]int
+}
+
+// newErrorReport retourne un rapport écrit dans w, ou dans stderr si w est nil
+func newErrorReport(w io.Writer, stderr io.Writer) (*errorReport, error) {
+	report := &errorReport{stderr: stderr, stages: map[string]int{}}
+	if w == nil {
+		return report, nil
+	}
+
+	report.writer = csv.NewWriter(w)
+	if err := report.writer.Write([]string{"line", "id", "source_id", "stage", "message", "text"}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// add signale l'erreur d'une posologie. Les fréquences absentes du catalogue
+// ne sont pas des échecs.
+func (r *errorReport) add(err *poso.ParseError, dosage poso.Dosage) error {
+	r.stages[err.Stage]++
+	if err.Stage != poso.StageCatalog {
+		r.failures++
+	}
+
+	if r.writer == nil {
+		_, writeErr := fmt.Fprintf(r.stderr, "Ligne %d (%s) : %s\n", err.Line, err.Stage, err.Message)
+		return writeErr
+	}
+
+	id := ""
+	if dosage.Id > 0 {
+		id = strconv.Itoa(dosage.Id)
+	}
+	return r.writer.Write([]string{strconv.Itoa(err.Line), id, dosage.SourceId, err.Stage, err.Message, dosage.Text})
+}
+
+// err retourne l'erreur de fin de lot s'il y a des lignes en échec
+func (r *errorReport) err() error {
+	if r.failures > 0 {
+		return fmt.Errorf("%d ligne(s) sur %d en échec", r.failures, r.total)
+	}
+	return nil
+}
+
+// summary résume le lot (ex: « 999 posologie(s), 997 analysée(s), 2 en échec
+// (normalisation : 1, validation : 1) »)
+func (r *errorReport) summary() string {
+	summary := fmt.Sprintf("Résumé : %d posologie(s), %d analysée(s), %d en échec", r.total, r.total-r.failures, r.failures)
+
+	var stages []string
+	for stage, count := range r.stages {
+		if stage != poso.StageCatalog {
+			stages = append(stages, fmt.Sprintf("%s : %d", stage, count))
+		}
+	}
+	if len(stages) > 0 {
+		slices.Sort(stages)
+		summary += " (" + strings.Join(stages, ", ") + ")"
+	}
+
+	if count := r.stages[poso.StageCatalog]; count > 0 {
+		summary += fmt.Sprintf(", %d fréquence(s) absente(s) du catalogue", count)
+	}
+	return summary
+}
+
+func (r *errorReport) flush() error {
+	if r.writer == nil {
+		return nil
+	}
+	r.writer.Flush()
+	return r.writer.Error()
+}
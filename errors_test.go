@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestErrorReport(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	report, err := newErrorReport(buffer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report.total = 5
+	errors := []*poso.ParseError{
+		{Line: 2, Stage: poso.StageValidation, Message: "posologie non analysée : SELON LES DIRECTIVES"},
+		{Line: 3, Stage: poso.StageCatalog, Message: "fréquence absente du catalogue : aux 36 heures"},
+		{Line: 7, Stage: poso.StageRead, Message: `extraneous or missing " in quoted-field`},
+		{Line: 9, Stage: poso.StageValidation, Message: "posologie non analysée : VOIR FEUILLET"},
+	}
+	for _, parseErr := range errors {
+		if err := report.add(parseErr, poso.Dosage{Id: parseErr.Line, SourceId: "RX", Text: "TEXTE, AVEC VIRGULE"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := report.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Résumé : 5 posologie(s), 2 analysée(s), 3 en échec (lecture : 1, validation : 2), 1 fréquence(s) absente(s) du catalogue"
+	if summary := report.summary(); summary != expected {
+		t.Errorf("E: %v\nA: %v", expected, summary)
+	}
+
+	if err := report.err(); err == nil || err.Error() != "3 ligne(s) sur 5 en échec" {
+		t.Errorf("E: %v\nA: %v", "3 ligne(s) sur 5 en échec", err)
+	}
+
+	rows, err := csv.NewReader(buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(errors)+1 || strings.Join(rows[1], "|") != "2|2|RX|validation|posologie non analysée : SELON LES DIRECTIVES|TEXTE, AVEC VIRGULE" {
+		t.Errorf("A: %q", rows)
+	}
+}
+
+// Sans rapport, les erreurs sont écrites dans stderr
+func TestErrorReportStderr(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	report, err := newErrorReport(nil, stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report.total = 1
+	if err := report.add(&poso.ParseError{Line: 4, Stage: poso.StageNormalization, Message: "texte invalide"}, poso.Dosage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "Ligne 4 (normalisation) : texte invalide\n"; stderr.String() != expected {
+		t.Errorf("E: %q\nA: %q", expected, stderr)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+// ExplainWriter écrit, pour chaque posologie, la règle qui a produit chacun
+// de ses champs : champ, identifiant de la règle, position et texte reconnu,
+// expression régulière. Les posologies doivent être analysées avec
+// Parser.Explain.
+type ExplainWriter struct {
+	writer *bufio.Writer
+}
+
+func NewExplainWriter(w io.Writer) *ExplainWriter {
+	return &ExplainWriter{writer: bufio.NewWriter(w)}
+}
+
+func (e *ExplainWriter) Write(dosage poso.Dosage) error {
+	fmt.Fprintf(e.writer, "%d : %s\n", dosage.Id, dosage.Text)
+	if french := poso.ToFrench(dosage); french != "" {
+		fmt.Fprintf(e.writer, "  → %s\n", french)
+	}
+
+	if len(dosage.Trace) == 0 {
+		_, err := fmt.Fprintf(e.writer, "  (aucune règle)\n\n")
+		return err
+	}
+
+	writer := tabwriter.NewWriter(e.writer, 0, 0, 2, ' ', 0)
+	for _, match := range dosage.Trace {
+		switch {
+		case match.Pattern == "":
+			fmt.Fprintf(writer, "  %s\t%s\t-\t(déduit)\n", match.Field, match.Rule)
+		case match.Start < 0:
+			fmt.Fprintf(writer, "  %s\t%s\t-\t%q\t%s\n", match.Field, match.Rule, match.Match, match.Pattern)
+		default:
+			fmt.Fprintf(writer, "  %s\t%s\t%d-%d\t%q\t%s\n", match.Field, match.Rule, match.Start, match.End, match.Text, match.Pattern)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	_, err := e.writer.WriteString("\n")
+	return err
+}
+
+func (e *ExplainWriter) Close() error {
+	return e.writer.Flush()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestExplainWriter(t *testing.T) {
+	parser := poso.NewParser()
+	parser.Explain = true
+
+	buffer := &bytes.Buffer{}
+	writer := NewExplainWriter(buffer)
+	for id, line := range []string{"Prendre 1 comprimé au coucher", "VOIR FEUILLET"} {
+		dosage, err := parser.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dosage.Id = id + 1
+		if err := writer.Write(dosage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"1 : Prendre 1 comprimé au coucher",
+		"  → Prendre 1 comprimé par la bouche 1 fois par jour au coucher",
+		`  dose       dose.comprimé       8-18   "1 comprimé"  (?P<dose>(((\d(\.|,))?\d+)( A |\-| \- | TO ))?((\d(\.|,))?\d+)) (?P<dose_unit>COMPRIMES|COMPRIME|TABLETS|TABLET|TABS|TAB\b|PILLS?)`,
+		"  route      voie.unité-de-dose  -      (déduit)",
+		`  frequency  fréquence.coucher   19-29  "au coucher"  (30 MINUTES|1/2 HEURE) AVANT LE COUCHER|AU COUCHER|NIGHTLY|BEDTIME|\bQHS\b`,
+		"",
+		"2 : VOIR FEUILLET",
+		"  (aucune règle)",
+		"",
+	}
+	actual := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("E: %q\nA: %q", expected, actual)
+	}
+}
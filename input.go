@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+// record est une posologie lue en entrée, avec son identifiant d'origine et
+// les colonnes à recopier dans la sortie
+type record struct {
+	line     int // première ligne du fichier où apparaît la posologie
+	text     string
+	sourceId string
+	columns  map[string]string
+}
+
+// recordReader lit les posologies une à une; Read retourne io.EOF à la fin
+type recordReader interface {
+	Read() (record, error)
+}
+
+// Options de lecture du fichier de posologies
+type inputOptions struct {
+	format    string
+	delimiter string
+	sigColumn string
+	idColumn  string
+	columns   string
+}
+
+func (o *inputOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.format, "input", "", "format d'entrée (text, csv ou tsv, selon l'extension de -in par défaut)")
+	flags.StringVar(&o.delimiter, "delimiter", "", "séparateur CSV (détecté entre , et ; par défaut)")
+	flags.StringVar(&o.sigColumn, "sig-column", "sig", "colonne CSV de la posologie (nom ou numéro à partir de 1)")
+	flags.StringVar(&o.idColumn, "id-column", "", "colonne CSV de l'identifiant d'origine (nom ou numéro)")
+	flags.StringVar(&o.columns, "columns", "", "colonnes CSV à recopier dans la sortie, séparées par des virgules")
+}
+
+// newRecordReader retourne le lecteur du format d'entrée demandé. path sert à
+// déduire le format de l'extension si -input est absent.
+func newRecordReader(r io.Reader, path string, options inputOptions) (recordReader, error) {
+	format := options.format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = "csv"
+		case ".tsv", ".tab":
+			format = "tsv"
+		default:
+			format = "text"
+		}
+	}
+
+	buffered := bufio.NewReader(r)
+
+	// Marque d'ordre des octets ajoutée par Excel
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+		buffered.Discard(3)
+	}
+
+	switch format {
+	case "text":
+		scanner := bufio.NewScanner(buffered)
+		scanner.Buffer(nil, maxLineSize)
+		return &textReader{scanner: scanner}, nil
+	case "csv", "tsv":
+		delimiter, err := csvDelimiter(buffered, format, options.delimiter)
+		if err != nil {
+			return nil, err
+		}
+		return newCsvReader(buffered, delimiter, options)
+	}
+	return nil, usageError{fmt.Sprintf("format d'entrée invalide : %s", format)}
+}
+
+// Taille maximale d'une ligne du fichier de posologies
+const maxLineSize = 1024 * 1024
+
+// Nombre maximal de lignes lues après un guillemet ouvrant pour trouver le
+// guillemet fermant
+const maxQuotedLines = 20
+
+// textReader lit une posologie par ligne. Une posologie qui commence par un
+// guillemet se poursuit jusqu'au guillemet fermant, même sur plusieurs lignes
+// (ex: "40 mL/h intraveineux⏎...⏎et cesser au moment de débuter le traitement");
+// ses lignes sont alors jointes par une espace. Sans guillemet fermant dans
+// les maxQuotedLines lignes suivantes, la première ligne est en échec et les
+// suivantes sont relues comme des posologies distinctes.
+type textReader struct {
+	scanner *bufio.Scanner
+	line    int      // numéro de la dernière ligne lue
+	pending []string // lignes relues avant celles du scanner
+}
+
+func (t *textReader) Read() (record, error) {
+	text, ok := t.next()
+	if !ok {
+		return record{}, t.err()
+	}
+	rec := record{line: t.line, text: text}
+	if !strings.HasPrefix(rec.text, `"`) {
+		return rec, nil
+	}
+
+	var lines, parts []string
+	text, closed := unquote(rec.text[1:])
+	for {
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+		if closed {
+			break
+		}
+
+		line, ok := "", len(lines) < maxQuotedLines
+		if ok {
+			line, ok = t.next()
+		}
+		if !ok {
+			if err := t.scanner.Err(); err != nil {
+				return record{}, err
+			}
+			t.pending = append(lines, t.pending...)
+			t.line -= len(lines)
+			return record{}, &poso.ParseError{Line: rec.line, Stage: poso.StageRead, Message: "guillemet non fermé : " + rec.text}
+		}
+		lines = append(lines, line)
+		text, closed = unquote(line)
+	}
+
+	rec.text = strings.Join(parts, " ")
+	return rec, nil
+}
+
+// next retourne la ligne suivante, relue ou lue par le scanner
+func (t *textReader) next() (string, bool) {
+	if len(t.pending) > 0 {
+		line := t.pending[0]
+		t.pending = t.pending[1:]
+		t.line++
+		return line, true
+	}
+	if !t.scanner.Scan() {
+		return "", false
+	}
+	t.line++
+	return t.scanner.Text(), true
+}
+
+func (t *textReader) err() error {
+	if err := t.scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// unquote retourne le texte jusqu'au guillemet fermant, s'il est présent,
+// suivi du reste de la ligne. Les guillemets doublés ("") sont conservés
+// simples.
+func unquote(line string) (string, bool) {
+	var builder strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] != '"' {
+			builder.WriteByte(line[i])
+			continue
+		}
+		if i+1 < len(line) && line[i+1] == '"' {
+			builder.WriteByte('"')
+			i++
+			continue
+		}
+		builder.WriteString(line[i+1:])
+		return builder.String(), true
+	}
+	return builder.String(), false
+}
+
+// csvDelimiter retourne le séparateur demandé, ou celui de l'en-tête : les
+// exports Excel en français utilisent le point-virgule.
+func csvDelimiter(r *bufio.Reader, format string, delimiter string) (rune, error) {
+	if delimiter == `\t` {
+		delimiter = "\t"
+	}
+	if delimiter != "" {
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return 0, usageError{fmt.Sprintf("séparateur invalide : %s", delimiter)}
+		}
+		separator, _ := utf8.DecodeRuneInString(delimiter)
+		return separator, nil
+	}
+
+	if format == "tsv" {
+		return '\t', nil
+	}
+
+	header, _ := r.Peek(4096)
+	if end := bytes.IndexByte(header, '\n'); end >= 0 {
+		header = header[:end]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';', nil
+	}
+	return ',', nil
+}
+
+// csvReader lit les posologies d'un fichier CSV avec une ligne d'en-tête
+type csvReader struct {
+	reader    *csv.Reader
+	sigColumn int
+	idColumn  int
+	columns   map[string]int
+}
+
+func newCsvReader(r io.Reader, delimiter rune, options inputOptions) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("fichier CSV vide")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &csvReader{reader: reader, idColumn: -1, columns: map[string]int{}}
+
+	c.sigColumn, err = findColumn(header, options.sigColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.idColumn != "" {
+		c.idColumn, err = findColumn(header, options.idColumn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.columns != "" {
+		for _, name := range strings.Split(options.columns, ",") {
+			index, err := findColumn(header, name)
+			if err != nil {
+				return nil, err
+			}
+			c.columns[strings.TrimSpace(header[index])] = index
+		}
+	}
+
+	return c, nil
+}
+
+func (c *csvReader) Read() (record, error) {
+	fields, err := c.reader.Read()
+	var csvErr *csv.ParseError
+	if errors.As(err, &csvErr) {
+		// Le lecteur CSV reprend à l'enregistrement suivant
+		return record{}, &poso.ParseError{Line: csvErr.StartLine, Stage: poso.StageRead, Message: csvErr.Err.Error()}
+	}
+	if err != nil {
+		return record{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	rec := record{line: line, text: field(fields, c.sigColumn)}
+	if c.idColumn >= 0 {
+		rec.sourceId = field(fields, c.idColumn)
+	}
+	if len(c.columns) > 0 {
+		rec.columns = map[string]string{}
+		for name, index := range c.columns {
+			rec.columns[name] = field(fields, index)
+		}
+	}
+	return rec, nil
+}
+
+// findColumn retourne l'index de la colonne column, donnée par son nom
+// (sans égard à la casse) ou par son numéro à partir de 1
+func findColumn(header []string, column string) (int, error) {
+	column = strings.TrimSpace(column)
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+
+	if number, err := strconv.Atoi(column); err == nil && number >= 1 && number <= len(header) {
+		return number - 1, nil
+	}
+	return 0, usageError{fmt.Sprintf("colonne absente de l'en-tête : %s", column)}
+}
+
+// field retourne le champ index, ou "" si la ligne est trop courte
+func field(fields []string, index int) string {
+	if index < len(fields) {
+		return fields[index]
+	}
+	return ""
+}
+
+// argsReader lit les posologies passées en arguments
+type argsReader struct {
+	sigs []string
+	line int
+}
+
+func (a *argsReader) Read() (record, error) {
+	if a.line == len(a.sigs) {
+		return record{}, io.EOF
+	}
+	a.line++
+	return record{line: a.line, text: a.sigs[a.line-1]}, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestRecordReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		path     string
+		options  inputOptions
+		expected []record
+		errors   []int // lignes des enregistrements illisibles
+	}{
+		{
+			name:     "texte",
+			input:    "PRENDRE 1 COMPRIME\nPRENDRE 2 COMPRIMES, AU COUCHER\n",
+			path:     "in.txt",
+			options:  inputOptions{sigColumn: "sig"},
+			expected: []record{{line: 1, text: "PRENDRE 1 COMPRIME"}, {line: 2, text: "PRENDRE 2 COMPRIMES, AU COUCHER"}},
+		},
+		{
+			name:    "texte avec posologies entre guillemets sur plusieurs lignes",
+			input:   "\"PRENEZ 1 COMPRIME 1 FOIS PAR JOUR LE MATIN\n\"\n\"40 mL/h intraveineux\nInstaller en voie primaire\net cesser au moment de débuter le traitement\"\nPRENDRE 2 \"PUFFS\"\n\"1 BOUFFEE \"\"AU BESOIN\"\"\" (ASTHME)\n",
+			path:    "in.txt",
+			options: inputOptions{sigColumn: "sig"},
+			expected: []record{
+				{line: 1, text: "PRENEZ 1 COMPRIME 1 FOIS PAR JOUR LE MATIN"},
+				{line: 3, text: "40 mL/h intraveineux Installer en voie primaire et cesser au moment de débuter le traitement"},
+				{line: 6, text: "PRENDRE 2 \"PUFFS\""},
+				{line: 7, text: "1 BOUFFEE \"AU BESOIN\" (ASTHME)"},
+			},
+		},
+		{
+			name:     "texte avec guillemet non fermé",
+			input:    "\"PRENDRE 2 COMPRIMES AU COUCHER\nPRENDRE 1 COMPRIME\nPRENDRE 1 CAPSULE\n",
+			path:     "in.txt",
+			options:  inputOptions{sigColumn: "sig"},
+			expected: []record{{line: 2, text: "PRENDRE 1 COMPRIME"}, {line: 3, text: "PRENDRE 1 CAPSULE"}},
+			errors:   []int{1},
+		},
+		{
+			name:     "texte avec guillemet fermé au-delà de maxQuotedLines",
+			input:    "\"PRENDRE 1 COMPRIME" + strings.Repeat("\nAU COUCHER", maxQuotedLines+1) + "\"\n",
+			path:     "in.txt",
+			options:  inputOptions{sigColumn: "sig"},
+			expected: append(textRecords(2, maxQuotedLines, "AU COUCHER"), record{line: maxQuotedLines + 2, text: "AU COUCHER\""}),
+			errors:   []int{1},
+		},
+		{
+			name:    "CSV Excel avec BOM et point-virgule",
+			input:   "\xEF\xBB\xBFNo Rx;Médicament;Posologie\nRX-1;ATORVASTATINE;PRENDRE 1 COMPRIME AU COUCHER\nRX-2;\"APO-AMOXI; 500 MG\";\"PRENDRE 1 CAPSULE 3 FOIS PAR JOUR\"\n",
+			path:    "export.csv",
+			options: inputOptions{sigColumn: "posologie", idColumn: "No Rx", columns: "Médicament"},
+			expected: []record{
+				{line: 2, text: "PRENDRE 1 COMPRIME AU COUCHER", sourceId: "RX-1", columns: map[string]string{"Médicament": "ATORVASTATINE"}},
+				{line: 3, text: "PRENDRE 1 CAPSULE 3 FOIS PAR JOUR", sourceId: "RX-2", columns: map[string]string{"Médicament": "APO-AMOXI; 500 MG"}},
+			},
+		},
+		{
+			name:    "CSV avec virgules et colonnes par numéro",
+			input:   "id,sig\n10,\"APPLIQUER 2G. SUR LES ZONES, 2 FOIS PAR JOUR\"\n11\n",
+			path:    "-",
+			options: inputOptions{format: "csv", sigColumn: "2", idColumn: "1"},
+			expected: []record{
+				{line: 2, text: "APPLIQUER 2G. SUR LES ZONES, 2 FOIS PAR JOUR", sourceId: "10"},
+				{line: 3, text: "", sourceId: "11"},
+			},
+		},
+		{
+			name:     "TSV",
+			input:    "id\tsig\n7\tPRENDRE 1 COMPRIME, 1 FOIS PAR JOUR\n",
+			path:     "export.tsv",
+			options:  inputOptions{sigColumn: "sig", idColumn: "id"},
+			expected: []record{{line: 2, text: "PRENDRE 1 COMPRIME, 1 FOIS PAR JOUR", sourceId: "7"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := newRecordReader(strings.NewReader(tc.input), tc.path, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []record
+			var readErrors []int
+			for {
+				rec, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+				var parseErr *poso.ParseError
+				if errors.As(err, &parseErr) {
+					readErrors = append(readErrors, parseErr.Line)
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, rec)
+			}
+
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("I: %q\nE: %+v\nA: %+v", tc.input, tc.expected, actual)
+			}
+			if !reflect.DeepEqual(readErrors, tc.errors) {
+				t.Errorf("Erreurs\nI: %q\nE: %v\nA: %v", tc.input, tc.errors, readErrors)
+			}
+		})
+	}
+}
+
+// textRecords retourne n enregistrements text à partir de la ligne line
+func textRecords(line int, n int, text string) []record {
+	var records []record
+	for i := 0; i < n; i++ {
+		records = append(records, record{line: line + i, text: text})
+	}
+	return records
+}
+
+func TestRecordReaderColumns(t *testing.T) {
+	testCases := []struct {
+		options inputOptions
+	}{
+		{options: inputOptions{format: "csv", sigColumn: "posologie"}},
+		{options: inputOptions{format: "csv", sigColumn: "sig", idColumn: "3"}},
+		{options: inputOptions{format: "csv", sigColumn: "sig", columns: "id,drug"}},
+		{options: inputOptions{format: "xls", sigColumn: "sig"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestRecordReaderColumns", func(t *testing.T) {
+			_, err := newRecordReader(strings.NewReader("id,sig\n1,PRENDRE 1 COMPRIME\n"), "-", tc.options)
+			if _, ok := err.(usageError); !ok {
+				t.Errorf("I: %+v\nE: %v\nA: %v", tc.options, "usageError", err)
+			}
+		})
+	}
+}
@@ -2,563 +2,217 @@ package main
 
 import (
 	"bufio"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"regexp"
 	"slices"
-	"strings"
-	"unicode"
+	"strconv"
 
-	"golang.org/x/text/runes"
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
+	"raphaelcoutu/traduction-poso/poso"
 )
 
-type Dosage struct {
-	Id          int    `json:"id"`
-	Text        string `json:"text"`
-	Dose        string `json:"dose"`
-	DoseUnit    string `json:"dose_unit"`
-	Route       string `json:"route"`
-	FrequencyId int    `json:"frequency_id"`
-	Frequency   string `json:"frequency"`
-}
-
 func main() {
-	file, err := os.Open("in_sample.txt")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer file.Close()
-
-	var dosages []Dosage
-	i := 1
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		oLine := scanner.Text()
-
-		dosage, err := MapAll(oLine)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		dosage.Id = i
-
-		dosages = append(dosages, dosage)
-
-		i++
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
-	}
-
-	PrintToJson(dosages)
-	PrintToText(dosages)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
 
-func PrintToJson(dosages []Dosage) {
-	jsonData, err := json.MarshalIndent(dosages, "", "  ")
-	if err != nil {
-		log.Fatal(err)
-		return
-	}
-
-	err = os.WriteFile("out.json", jsonData, 0644)
-	if err != nil {
-		log.Fatal(err)
-		return
-	}
+// DosageWriter écrit les posologies au fur et à mesure de leur analyse.
+// Close termine la sortie (ex: fin du tableau JSON) sans fermer le fichier.
+type DosageWriter interface {
+	Write(dosage poso.Dosage) error
+	Close() error
 }
 
-func PrintToText(dosages []Dosage) {
-	// write to text file
-	outFile, err := os.Create("out.txt")
-	if err != nil {
-		log.Fatal(err)
-		return
-	}
-	defer outFile.Close()
-
-	for _, dosage := range dosages {
-		sDosage := fmt.Sprintf("%s, %s, %s, %s", dosage.Text, dosage.Dose, dosage.DoseUnit, dosage.Frequency)
-		_, err = outFile.WriteString(sDosage + "\n")
-		if err != nil {
-			log.Fatal(err)
-			return
-		}
-	}
-
+// JsonWriter écrit les posologies en un tableau JSON indenté, un élément à
+// la fois
+type JsonWriter struct {
+	writer *bufio.Writer
+	count  int
 }
 
-func MapAll(line string) (Dosage, error) {
-	dosage := Dosage{}
-
-	dosage.Text = line
-
-	line = strings.ToUpper(line)
-	line, err := RemoveAccents(line)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	dosage.Dose, dosage.DoseUnit = MapDose(line)
-	dosage.Route = MapRoute(line, dosage)
-
-	dosage.FrequencyId, dosage.Frequency = MapFrequency(line)
-
-	return dosage, nil
+func NewJsonWriter(w io.Writer) *JsonWriter {
+	return &JsonWriter{writer: bufio.NewWriter(w)}
 }
 
-func MapDose(line string) (string, string) {
-
-	if isComplexDosage(line) {
-		return "", ""
-	}
-
-	line = RemoveFraction(line)
-
-	re := regexp.MustCompile(`((((\d(\.|,))?\d+)( A |\-| \- ))?((\d(\.|,))?\d+)) (COMPRIMES|COMPRIME|TABLETS|TABLET)`)
-	if re.MatchString(line) {
-		dose := strings.Replace(re.FindStringSubmatch(line)[1], "A", "-", -1)
-		dose = strings.Replace(dose, " ", "", -1)
-		dose = strings.Replace(dose, ",", ".", -1)
-		return dose, "comprimé"
-	}
-
-	re = regexp.MustCompile(`(((\d+)( A |\-| \- ))?((\d(\.|,))?\d+)) (CAPSULES|CAPSULE)`)
-	if re.MatchString(line) {
-		dose := strings.Replace(re.FindStringSubmatch(line)[1], "A", "-", -1)
-		dose = strings.Replace(dose, " ", "", -1)
-		dose = strings.Replace(dose, ",", ".", -1)
-		return dose, "capsule"
-	}
-
-	if regexp.MustCompile(`(LA|UNE) CAPSULE`).MatchString(line) {
-		return "1", "capsule"
-	}
-
-	re = regexp.MustCompile(`([0-9]+) (VAPORISATIONS|VAPORISATION)`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "vaporisation"
-	}
-
-	re = regexp.MustCompile(`VAPORISE(?:R|Z) ([0-9]+) FOIS`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "vaporisation"
-	}
-
-	re = regexp.MustCompile(`(VAPORISER|VAPORISEZ)`)
-	if re.MatchString(line) {
-		return "1", "vaporisation"
-	}
-
-	re = regexp.MustCompile(`([0-9]+) (INHALATIONS|INHALATION)`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "bouffée"
-	}
-
-	re = regexp.MustCompile(`([0-9]+) (GOUTTES?|G )`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "goutte"
-	}
-
-	re = regexp.MustCompile(`([0-9]+) (GRAMMES?|G )`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "g"
+func (j *JsonWriter) Write(dosage poso.Dosage) error {
+	jsonData, err := json.MarshalIndent(dosage, "  ", "  ")
+	if err != nil {
+		return err
 	}
 
-	re = regexp.MustCompile(`([0-9]+)(GRAMMES?|G|G\. )`)
-	if re.MatchString(line) {
-		return re.FindStringSubmatch(line)[1], "g"
+	separator := ",\n  "
+	if j.count == 0 {
+		separator = "[\n  "
 	}
+	j.count++
 
-	re = regexp.MustCompile(`([0-9]+|UN) (TIMBRES?|PATCHS?)`)
-	if re.MatchString(line) {
-		if re.FindStringSubmatch(line)[1] == "UN" {
-			return "1", "timbre"
-		}
-		return re.FindStringSubmatch(line)[1], "timbre"
+	if _, err := j.writer.WriteString(separator); err != nil {
+		return err
 	}
-
-	return "", ""
+	_, err = j.writer.Write(jsonData)
+	return err
 }
 
-func MapRoute(line string, dosage Dosage) string {
-
-	if strings.Contains(line, "CHAQUE NARINE") || strings.Contains(line, "DANS LES NARINES") {
-		return "nasale"
-	}
-
-	if regexp.MustCompile(`INTRA(-|\s)?MUSCULAIRE`).MatchString(line) {
-		return "intramusculaire"
-	}
-
-	if regexp.MustCompile(`SOUS(-|\s)?CUTANEE`).MatchString(line) {
-		return "sous-cutané"
-	}
-
-	if strings.Contains(line, "OEIL") || strings.Contains(line, "ŒIL") || strings.Contains(line, "YEUX") {
-		if strings.Contains(line, "GAUCHE") {
-			return "oeil gauche"
-		} else if strings.Contains(line, "DROIT") {
-			return "oeil droit"
-		} else if strings.Contains(line, "YEUX") {
-			return "dans les 2 yeux"
-		}
-		return "oculaire"
-	}
-
-	if regexp.MustCompile(`OREILLES?`).MatchString(line) {
-		if strings.Contains(line, "GAUCHE") {
-			return "oreille gauche"
-		} else if strings.Contains(line, "DROIT") {
-			return "oreille droit"
-		} else if strings.Contains(line, "OREILLES") {
-			return "dans les 2 oreilles"
-		}
-		return "otique"
-	}
-
-	if regexp.MustCompile(`APPLIQUE(R|Z)|APPLICATION LOCALE`).MatchString(line) {
-		return "topique"
-	}
-
-	if strings.Contains(line, "BOIRE") || regexp.MustCompile(`DISSOU.*\sVERRE D'EAU`).MatchString(line) {
-		return "oral"
-	}
-
-	if strings.Contains(line, "SOUS LA LANGUE") {
-		return "sublingual"
+func (j *JsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
 	}
-
-	if regexp.MustCompile(`INHALE(R|Z) (LE CONTENU D'UNE )?CAPSULE`).MatchString(line) {
-		return "inhalation"
-	}
-
-	if slices.Contains([]string{"comprimé", "capsule"}, dosage.DoseUnit) {
-		return "oral"
-	} else if dosage.DoseUnit == "bouffée" {
-		return "inhalation"
-	} else if dosage.DoseUnit == "timbre" {
-		return "topique"
+	if _, err := j.writer.WriteString(end); err != nil {
+		return err
 	}
-
-	return ""
+	return j.writer.Flush()
 }
 
-func MapFrequency(line string) (int, string) {
-	isPrn := false
-	withFood := false
-
-	if isComplexDosage(line) {
-		return 0, ""
-	}
-
-	if strings.Contains(line, "PRN") || strings.Contains(line, "AU BESOIN") || strings.Contains(line, "SI BESOIN") || strings.Contains(line, "AS NEEDED") || regexp.MustCompile(`SI (DOULEURS?)`).MatchString(line) {
-		isPrn = true
-	}
-
-	if strings.Contains(line, "EN MANGEANT") || strings.Contains(line, "AVEC NOURRITURE") {
-		withFood = true
-	}
-
-	// # FOIS PAR JOUR (FR)
-	re := regexp.MustCompile(`([0-9]+|UNE) FOIS PAR JOUR`)
-	if re.MatchString(line) {
-		freq := re.FindStringSubmatch(line)[1]
+// NdjsonWriter écrit une posologie JSON par ligne (NDJSON)
+type NdjsonWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
 
-		if isPrn {
-			// prn = true
-			if freq == "1" || freq == "UNE" {
-				if regexp.MustCompile(`(AU|AVEC LE) DEJEUNER`).MatchString(line) {
-					return 0, "1 fois par jour au déjeuner PRN"
-				} else if regexp.MustCompile(`AVANT LE DEJEUNER`).MatchString(line) {
-					return 0, "1 fois par jour avant le déjeuner PRN"
-				} else if strings.Contains(line, "LE MATIN") {
-					return 0, "1 fois par jour le matin PRN"
-				} else if strings.Contains(line, "AU DINER") {
-					return 0, "1 fois par jour au dîner PRN"
-				} else if strings.Contains(line, "AU SOUPER") {
-					return 0, "1 fois par jour au souper PRN"
-				} else if regexp.MustCompile(`AVANT (LE )?COUCHER`).MatchString(line) {
-					return 0, "1 fois par jour au coucher PRN"
-				} else if strings.Contains(line, "AU COUCHER") {
-					return 0, "1 fois par jour au coucher PRN"
-				}
+func NewNdjsonWriter(w io.Writer) *NdjsonWriter {
+	writer := bufio.NewWriter(w)
+	return &NdjsonWriter{writer: writer, encoder: json.NewEncoder(writer)}
+}
 
-				return 0, "1 fois par jour PRN"
-			} else if freq == "2" {
-				return 0, "2 fois par jour PRN"
-			} else if freq == "3" {
-				return 0, "3 fois par jour PRN"
-			} else if freq == "4" {
-				return 0, "4 fois par jour PRN"
-			}
-		} else {
-			// prn = false
-			if freq == "1" || freq == "UNE" {
-				if regexp.MustCompile(`(AU|AVEC LE) DEJEUNER`).MatchString(line) {
-					return 0, "1 fois par jour au déjeuner"
-				} else if regexp.MustCompile(`AVANT LE DEJEUNER`).MatchString(line) {
-					return 0, "1 fois par jour avant le déjeuner"
-				} else if strings.Contains(line, "LE MATIN") {
-					return 0, "1 fois par jour le matin"
-				} else if strings.Contains(line, "AU DINER") {
-					return 0, "1 fois par jour au dîner"
-				} else if strings.Contains(line, "AU SOUPER") {
-					return 0, "1 fois par jour au souper"
-				} else if regexp.MustCompile(`AVANT (LE )?COUCHER`).MatchString(line) {
-					return 0, "1 fois par jour au coucher"
-				} else if strings.Contains(line, "AU COUCHER") {
-					return 0, "1 fois par jour au coucher"
-				}
+func (n *NdjsonWriter) Write(dosage poso.Dosage) error {
+	return n.encoder.Encode(dosage)
+}
 
-				return 0, "1 fois par jour"
-			} else if freq == "2" {
+func (n *NdjsonWriter) Close() error {
+	return n.writer.Flush()
+}
 
-				if strings.Contains(line, "DEJEUNER") && strings.Contains(line, "SOUPER") {
-					return 0, "2 fois par jour au déjeuner et au souper"
-				} else if strings.Contains(line, "MATIN") && strings.Contains(line, "SOIR") && withFood {
-					return 0, "2 fois par jour au déjeuner et au souper"
-				}
+// LineWriter écrit une ligne par posologie à l'aide de format (ex: ToFrench, ToEnglish)
+type LineWriter struct {
+	writer *bufio.Writer
+	format func(poso.Dosage) string
+}
 
-				return 0, "2 fois par jour"
-			} else if freq == "3" {
-				return 0, "3 fois par jour"
-			} else if freq == "4" {
-				return 0, "4 fois par jour"
-			}
-		}
-	}
+func NewLineWriter(w io.Writer, format func(poso.Dosage) string) *LineWriter {
+	return &LineWriter{writer: bufio.NewWriter(w), format: format}
+}
 
-	// # FOIS PAR JOUR (EN)
-	if strings.Contains(line, "DAILY") {
-		if strings.Contains(line, "TWICE") {
-			//
-		} else if strings.Contains(line, "THREE TIMES") {
-			//
-		} else {
-			if isPrn {
-				return 0, "1 fois par jour PRN"
-			} else {
-				if strings.Contains(line, "BREAKFAST") {
-					return 0, "1 fois par jour au déjeuner"
-				} else if strings.Contains(line, "IN THE MORNING") {
-					return 0, "1 fois par jour le matin"
-				} else if strings.Contains(line, "AT BEDTIME") {
-					return 0, "1 fois par jour au coucher"
-				}
-				return 0, "1 fois par jour"
-			}
-		}
-	}
+// NewTextWriter écrit une ligne par posologie : texte, dose, unité et
+// fréquence (format historique de out.txt). La posologie normalisée est
+// disponible avec -format fr ou csv.
+func NewTextWriter(w io.Writer) *LineWriter {
+	return NewLineWriter(w, func(dosage poso.Dosage) string {
+		return fmt.Sprintf("%s, %s, %s, %s", dosage.Text, dosage.Dose, dosage.DoseUnit, dosage.Frequency)
+	})
+}
 
-	// AUX # HEURES
-	re = regexp.MustCompile(`(?:AUX|TOU(?:TE)?S LES) ([0-9]+)( A [0-9]+)?\s+HEURES`)
-	if re.MatchString(line) {
-		freq := re.FindStringSubmatch(line)[1]
+func (l *LineWriter) Write(dosage poso.Dosage) error {
+	_, err := l.writer.WriteString(l.format(dosage) + "\n")
+	return err
+}
 
-		if isPrn {
-			// prn = true
-			if freq == "3" {
-				return 0, "q3h PRN"
-			} else if freq == "4" {
-				return 0, "q4h PRN"
-			} else if freq == "6" {
-				return 0, "q6h PRN"
-			} else if freq == "8" {
-				return 0, "q8h PRN"
-			} else if freq == "12" {
-				return 0, "q8h PRN"
-			} else if freq == "24" {
-				return 0, "q8h PRN"
-			}
-		} else {
-			// prn = false
-			if freq == "3" {
-				return 0, "q3h"
-			} else if freq == "4" {
-				return 0, "q4h"
-			} else if freq == "6" {
-				return 0, "q6h"
-			} else if freq == "8" {
-				return 0, "q8h"
-			} else if freq == "12" {
-				return 0, "q8h"
-			} else if freq == "24" {
-				return 0, "q8h"
-			}
-		}
-	}
+func (l *LineWriter) Close() error {
+	return l.writer.Flush()
+}
 
-	// AUX # HEURES
-	re = regexp.MustCompile(`AUX ([0-9]+)( A [0-9]+)?\s+MIN(UTES|UTE)?`)
-	if re.MatchString(line) {
-		freq := re.FindStringSubmatch(line)[1]
+// En-tête CSV : un champ par colonne, les champs imbriqués de Dosage étant
+// aplatis (frequency_times, duration_unit, etc.)
+var csvHeader = []string{
+	"id", "source_id", "text",
+	"dose", "dose_min", "dose_max", "dose_unit", "route",
+	"frequency_id", "frequency", "frequency_once", "frequency_times", "frequency_period",
+	"frequency_interval_min", "frequency_interval_max", "frequency_interval_unit",
+	"frequency_event", "frequency_timings", "frequency_prn",
+	"duration_min", "duration_max", "duration_unit", "duration_indefinite",
+	"max_doses", "prn", "prn_reason", "indication_term", "indication_text", "steps", "spans", "french",
+}
 
-		if isPrn {
-			// prn = true
-			if freq == "5" {
-				return 0, "q5min PRN"
-			} else if freq == "10" {
-				return 0, "q10min PRN"
-			} else if freq == "15" {
-				return 0, "q15min PRN"
-			} else if freq == "30" {
-				return 0, "q30min PRN"
-			}
-		} else {
-			// prn = false
-			if freq == "5" {
-				return 0, "q5min"
-			} else if freq == "10" {
-				return 0, "q10min"
-			} else if freq == "15" {
-				return 0, "q15min"
-			} else if freq == "30" {
-				return 0, "q30min"
-			}
-		}
-	}
+// CsvWriter écrit les posologies en CSV (RFC 4180) avec une ligne d'en-tête.
+// Les listes (moments, doses maximales, étapes) et les positions des éléments
+// sont encodées en JSON; la colonne french est la posologie normalisée
+// (ToFrench). Les colonnes recopiées de l'entrée suivent les colonnes de
+// Dosage.
+type CsvWriter struct {
+	writer  *csv.Writer
+	columns []string
+	started bool
+}
 
-	// # FOIS PAR SEMAINE
-	re = regexp.MustCompile(`([0-9]+) FOIS PAR SEMAINE|(ONCE|TWICE) A WEEK`)
-	if re.MatchString(line) {
-		freq := re.FindStringSubmatch(line)[1]
-		freqEn := re.FindStringSubmatch(line)[2]
+func NewCsvWriter(w io.Writer) *CsvWriter {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	return &CsvWriter{writer: writer}
+}
 
-		if isPrn {
-			// prn = true
-			if freq == "1" || freqEn == "ONCE" {
-				return 0, "1 fois par semaine PRN"
-			} else if freq == "2" {
-				return 0, "2 fois par semaine PRN"
-			} else if freq == "3" {
-				return 0, "3 fois par semaine PRN"
-			}
-		} else {
-			// prn = false
-			if freq == "1" || freqEn == "ONCE" {
-				return 0, "1 fois par semaine"
-			} else if freq == "2" {
-				return 0, "2 fois par semaine"
-			} else if freq == "3" {
-				return 0, "3 fois par semaine"
-			}
-		}
+// writeHeader écrit l'en-tête; les colonnes recopiées sont celles de la
+// première posologie
+func (c *CsvWriter) writeHeader(dosage poso.Dosage) error {
+	c.started = true
+	for name := range dosage.Columns {
+		c.columns = append(c.columns, name)
 	}
+	slices.Sort(c.columns)
 
-	// PAR JOUR (mais pas MAXIMUM # COMPRIMES PAR JOUR)
-	re = regexp.MustCompile(`[0-9]+ (COMPRIMES?|CAPSULES?) PAR JOUR`)
-	matches := re.FindAllString(line, -1)
+	return c.writer.Write(append(slices.Clone(csvHeader), c.columns...))
+}
 
-	var filteredMatches []string
-	for _, match := range matches {
-		if !strings.Contains(line[:strings.Index(line, match)], "MAXIMUM") {
-			filteredMatches = append(filteredMatches, match)
-		}
-	}
-	if filteredMatches != nil {
-		if isPrn {
-			return 0, "1 fois par jour PRN"
-		} else {
-			if strings.Contains(line, "30 MINUTES AVANT LE COUCHER") {
-				return 0, "1 fois par jour au coucher PRN"
-			} else if regexp.MustCompile(`(AU|AVEC LE) DEJEUNER`).MatchString(line) {
-				return 0, "1 fois par jour au déjeuner"
-			}
+func (c *CsvWriter) Write(dosage poso.Dosage) error {
+	if !c.started {
+		if err := c.writeHeader(dosage); err != nil {
+			return err
 		}
-
-		return 0, "1 fois par jour"
 	}
 
-	// PAR SEMAINE (mais pas MAXIMUM # COMPRIMES PAR SEMAINE)
-	re = regexp.MustCompile(`[0-9]+ (COMPRIMES?|CAPSULES?|TIMBRES?) PAR SEMAINE`)
-	matches = re.FindAllString(line, -1)
-
-	filteredMatches = nil
-	for _, match := range matches {
-		if !strings.Contains(line[:strings.Index(line, match)], "MAXIMUM") {
-			filteredMatches = append(filteredMatches, match)
-		}
+	frequency, duration := dosage.FrequencyDetail, dosage.Duration
+	fields := []string{
+		strconv.Itoa(dosage.Id), dosage.SourceId, dosage.Text,
+		dosage.Dose, formatFloat(dosage.DoseMin), formatFloat(dosage.DoseMax), dosage.DoseUnit, dosage.Route,
+		strconv.Itoa(dosage.FrequencyId), dosage.Frequency, strconv.FormatBool(frequency.Once), strconv.Itoa(frequency.Times), frequency.Period,
+		strconv.Itoa(frequency.IntervalMin), strconv.Itoa(frequency.IntervalMax), frequency.IntervalUnit,
+		frequency.Event, jsonList(frequency.Timings), strconv.FormatBool(frequency.Prn),
+		formatFloat(duration.Min), formatFloat(duration.Max), duration.Unit, strconv.FormatBool(duration.Indefinite),
+		jsonList(dosage.MaxDoses), strconv.FormatBool(dosage.Prn), dosage.PrnReason, dosage.Indication.Term, dosage.Indication.Text, jsonList(dosage.Steps),
+		jsonSpans(dosage.Spans), poso.ToFrench(dosage),
 	}
-	if filteredMatches != nil {
-		return 0, "1 fois par semaine"
-	}
-
-	if strings.Contains(line, "LE MATIN") {
-		if isPrn {
-			return 0, "1 fois par jour le matin PRN"
-		} else {
-			return 0, "1 fois par jour le matin"
-		}
+	for _, name := range c.columns {
+		fields = append(fields, dosage.Columns[name])
 	}
 
-	if regexp.MustCompile(`(30 MINUTES|1/2 HEURE) AVANT LE COUCHER`).MatchString(line) {
-		if isPrn {
-			return 0, "1 fois par jour au coucher PRN"
-		} else {
-			return 0, "1 fois par jour au coucher"
-		}
-	}
+	return c.writer.Write(fields)
+}
 
-	re = regexp.MustCompile(`UNE SEULE DOSE|DOSE UNIQUE|UNE SEULE PRISE|IMMEDIATEMENT`)
-	if re.MatchString(line) {
-		if isPrn {
-			return 0, "1 fois PRN"
-		} else {
-			return 0, "1 fois"
+func (c *CsvWriter) Close() error {
+	if !c.started {
+		if err := c.writeHeader(poso.Dosage{}); err != nil {
+			return err
 		}
 	}
-
-	if regexp.MustCompile(`GARDER 24 HEURES,? ?RETIRER .* CHANGER`).MatchString(line) {
-		return 0, "1 fois par jour"
-	}
-
-	return 0, ""
+	c.writer.Flush()
+	return c.writer.Error()
 }
 
-func RemoveAccents(text string) (string, error) {
-	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
-	result, _, err := transform.String(t, text)
-	if err != nil {
-		return "", err
-	}
-	return result, nil
+func formatFloat(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
 }
 
-func RemoveFraction(text string) string {
-	text = strings.Replace(text, "½", "1/2", -1)
-	text = strings.Replace(text, "¼", "1/4", -1)
-	text = strings.Replace(text, "¾", "3/4", -1)
-
-	if strings.Contains(text, "1 1/2") {
-		text = strings.Replace(text, "1 1/2", "1.5", -1)
-	} else if strings.Contains(text, "1 1/4") {
-		text = strings.Replace(text, "1 1/4", "1.25", -1)
-	} else if strings.Contains(text, "1/2") {
-		text = strings.Replace(text, "1/2", "0.5", -1)
-	} else if strings.Contains(text, "1/4") {
-		text = strings.Replace(text, "1/4", "0.25", -1)
-	} else if strings.Contains(text, "3/4") {
-		text = strings.Replace(text, "3/4", "0.75", -1)
+// jsonList encode une liste en JSON, ou retourne "" si elle est vide
+func jsonList[T any](list []T) string {
+	if len(list) == 0 {
+		return ""
 	}
-
-	return text
+	jsonData, err := json.Marshal(list)
+	if err != nil {
+		return ""
+	}
+	return string(jsonData)
 }
 
-func isComplexDosage(line string) bool {
-	if regexp.MustCompile(`PUIS ([0-9]+) (?:COMPRIMES?|CAPSULES?)`).MatchString(line) {
-		return true
+// jsonSpans encode les positions des éléments en JSON, ou retourne "" s'il
+// n'y en a aucune
+func jsonSpans(spans poso.Spans) string {
+	if spans == (poso.Spans{}) {
+		return ""
 	}
-
-	if regexp.MustCompile(`MAINTENANT.*CHAQUE SELLE`).MatchString(line) {
-		return true
+	jsonData, err := json.Marshal(spans)
+	if err != nil {
+		return ""
 	}
-
-	return false
+	return string(jsonData)
 }
@@ -0,0 +1,112 @@
+package poso
+
+import (
+	"sync"
+)
+
+// Nombre maximal de posologies en cours d'analyse par goroutine, pour limiter
+// la mémoire lorsqu'une ligne est beaucoup plus lente que les suivantes
+const pendingPerWorker = 64
+
+type batchJob struct {
+	id   int
+	text string
+}
+
+type batchResult struct {
+	dosage Dosage
+	err    error
+}
+
+// ParseOrdered analyse les posologies retournées par next avec workers
+// goroutines et appelle emit pour chacune, dans l'ordre de next. Id vaut le
+// rang de la posologie (à partir de 1), comme pour une analyse séquentielle,
+// tout comme ParseError.Line en cas d'échec.
+//
+// next et emit sont toujours appelés depuis la goroutine de l'appelant. Si
+// emit retourne une erreur, la lecture s'arrête et cette erreur est retournée.
+func (p *Parser) ParseOrdered(workers int, next func() (string, bool), emit func(Dosage, error) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan batchJob)
+	results := make(chan batchResult, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				dosage, err := p.Parse(job.text)
+				dosage.Id = job.id
+				if parseErr, ok := err.(*ParseError); ok {
+					parseErr.Line = job.id
+				}
+				results <- batchResult{dosage, err}
+			}
+		}()
+	}
+
+	pending := map[int]batchResult{}
+	read, emitted := 0, 0
+	var job batchJob
+	hasJob, closed := false, false
+	var emitErr error
+
+	for {
+		// Lecture de la prochaine posologie si la limite n'est pas atteinte
+		if !hasJob && !closed && read-emitted < workers*pendingPerWorker {
+			if text, ok := next(); ok {
+				read++
+				job, hasJob = batchJob{read, text}, true
+			} else {
+				close(jobs)
+				closed = true
+			}
+		}
+
+		if closed && emitted == read {
+			break
+		}
+
+		var send chan<- batchJob
+		if hasJob {
+			send = jobs
+		}
+
+		select {
+		case send <- job:
+			hasJob = false
+		case result := <-results:
+			pending[result.dosage.Id] = result
+
+			// Émission dans l'ordre des posologies déjà analysées
+			for {
+				result, ok := pending[emitted+1]
+				if !ok {
+					break
+				}
+				delete(pending, emitted+1)
+				emitted++
+
+				if emitErr == nil {
+					emitErr = emit(result.dosage, result.err)
+				}
+			}
+
+			if emitErr != nil && !closed {
+				if hasJob {
+					read--
+					hasJob = false
+				}
+				close(jobs)
+				closed = true
+			}
+		}
+	}
+
+	wg.Wait()
+	return emitErr
+}
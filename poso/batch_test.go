@@ -0,0 +1,77 @@
+package poso
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// L'analyse parallèle donne les mêmes posologies, dans le même ordre et avec
+// les mêmes Id, qu'une analyse séquentielle
+func TestParseOrdered(t *testing.T) {
+	file, err := os.Open("../in_sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	parser := NewParser()
+	expected := make([]Dosage, len(lines))
+	for i, line := range lines {
+		expected[i], _ = parser.Parse(line)
+		expected[i].Id = i + 1
+	}
+
+	for _, workers := range []int{0, 1, 4, 16} {
+		i := 0
+		next := func() (string, bool) {
+			if i == len(lines) {
+				return "", false
+			}
+			i++
+			return lines[i-1], true
+		}
+
+		var actual []Dosage
+		err := parser.ParseOrdered(workers, next, func(dosage Dosage, err error) error {
+			actual = append(actual, dosage)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%d goroutines : posologies différentes de l'analyse séquentielle", workers)
+		}
+	}
+}
+
+// Une erreur de emit arrête la lecture
+func TestParseOrderedEmitError(t *testing.T) {
+	stop := errors.New("arrêt")
+	read, emitted := 0, 0
+
+	err := NewParser().ParseOrdered(4, func() (string, bool) {
+		read++
+		return "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR", read <= 10000
+	}, func(dosage Dosage, err error) error {
+		emitted++
+		if dosage.Id == 10 {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop || emitted != 10 || read >= 10000 {
+		t.Errorf("E: %v\nA: %v (%d lues, %d émises)", stop, err, read, emitted)
+	}
+}
@@ -0,0 +1,125 @@
+package poso
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func readLines(b *testing.B, path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// Analyse complète de in_sample.txt
+func BenchmarkParse(b *testing.B) {
+	lines := readLines(b, "../in_sample.txt")
+	parser := NewParser()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			parser.Parse(line)
+		}
+	}
+}
+
+// Analyse complète du corpus anglais
+func BenchmarkParseEnglish(b *testing.B) {
+	lines := readLines(b, "../translate/testdata/in.txt")
+	parser := NewParser()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			parser.Parse(line)
+		}
+	}
+}
+
+func BenchmarkMapDose(b *testing.B) {
+	lines := normalizedLines(b, "../in_sample.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			MapDose(line)
+		}
+	}
+}
+
+func BenchmarkMapRoute(b *testing.B) {
+	lines := normalizedLines(b, "../in_sample.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			MapRoute(line, Dosage{})
+		}
+	}
+}
+
+func BenchmarkMapFrequency(b *testing.B) {
+	lines := normalizedLines(b, "../in_sample.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			MapFrequency(line)
+		}
+	}
+}
+
+// normalizedLines retourne les lignes en majuscules sans accents, comme
+// Parse les transmet aux fonctions Map*
+func normalizedLines(b *testing.B, path string) []string {
+	lines := readLines(b, path)
+	for i, line := range lines {
+		normalized, err := RemoveAccents(strings.ToUpper(line))
+		if err != nil {
+			b.Fatal(err)
+		}
+		lines[i] = normalized
+	}
+	return lines
+}
+
+// Ancienne méthode : chaque règle est compilée à chaque appel
+func BenchmarkRulesCompiledPerCall(b *testing.B) {
+	lines := normalizedLines(b, "../in_sample.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			for _, r := range rules {
+				regexp.MustCompile(r.re.String()).FindStringSubmatch(line)
+			}
+		}
+	}
+}
+
+// Règles précompilées avec filtre par mots-clés
+func BenchmarkRulesPrecompiled(b *testing.B) {
+	lines := normalizedLines(b, "../in_sample.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			for _, r := range rules {
+				r.FindStringSubmatch(line)
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package poso
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CatalogEntry associe une fréquence à son identifiant dans le catalogue
+type CatalogEntry struct {
+	Id        int       `json:"id"`
+	Label     string    `json:"label"`
+	Frequency Frequency `json:"frequency"`
+}
+
+type FrequencyCatalog []CatalogEntry
+
+// Lookup retourne l'identifiant de la fréquence dans le catalogue
+func (c FrequencyCatalog) Lookup(frequency Frequency) (int, bool) {
+	if frequency.IsZero() {
+		return 0, false
+	}
+
+	for _, entry := range c {
+		if entry.Frequency.Equal(frequency) {
+			return entry.Id, true
+		}
+	}
+
+	// Entrées définies seulement par leur libellé
+	label := frequency.Label()
+	for _, entry := range c {
+		if entry.Frequency.IsZero() && entry.Label == label {
+			return entry.Id, true
+		}
+	}
+
+	return 0, false
+}
+
+// Fréquences régulières du catalogue par défaut. La variante PRN de chaque
+// fréquence a l'identifiant + 100.
+var defaultFrequencies = []CatalogEntry{
+	{Id: 1, Frequency: Frequency{Times: 1, Period: "jour"}},
+	{Id: 2, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"matin"}}},
+	{Id: 3, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"déjeuner"}}},
+	{Id: 4, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"avant déjeuner"}}},
+	{Id: 5, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"dîner"}}},
+	{Id: 6, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"souper"}}},
+	{Id: 7, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"coucher"}}},
+	{Id: 8, Frequency: Frequency{Times: 2, Period: "jour"}},
+	{Id: 9, Frequency: Frequency{Times: 2, Period: "jour", Timings: []string{"déjeuner", "souper"}}},
+	{Id: 10, Frequency: Frequency{Times: 3, Period: "jour"}},
+	{Id: 11, Frequency: Frequency{Times: 4, Period: "jour"}},
+	{Id: 12, Frequency: Frequency{IntervalMin: 3, IntervalUnit: "h"}},
+	{Id: 13, Frequency: Frequency{IntervalMin: 4, IntervalUnit: "h"}},
+	{Id: 14, Frequency: Frequency{IntervalMin: 4, IntervalMax: 6, IntervalUnit: "h"}},
+	{Id: 15, Frequency: Frequency{IntervalMin: 6, IntervalUnit: "h"}},
+	{Id: 16, Frequency: Frequency{IntervalMin: 8, IntervalUnit: "h"}},
+	{Id: 17, Frequency: Frequency{IntervalMin: 12, IntervalUnit: "h"}},
+	{Id: 18, Frequency: Frequency{IntervalMin: 24, IntervalUnit: "h"}},
+	{Id: 19, Frequency: Frequency{IntervalMin: 5, IntervalUnit: "min"}},
+	{Id: 20, Frequency: Frequency{IntervalMin: 10, IntervalUnit: "min"}},
+	{Id: 21, Frequency: Frequency{IntervalMin: 15, IntervalUnit: "min"}},
+	{Id: 22, Frequency: Frequency{IntervalMin: 30, IntervalUnit: "min"}},
+	{Id: 23, Frequency: Frequency{Times: 1, Period: "semaine"}},
+	{Id: 24, Frequency: Frequency{Times: 2, Period: "semaine"}},
+	{Id: 25, Frequency: Frequency{Times: 3, Period: "semaine"}},
+	{Id: 26, Frequency: Frequency{Once: true}},
+	{Id: 27, Frequency: Frequency{Event: "selle"}},
+	{Id: 28, Frequency: Frequency{Times: 1, Period: "jour", Timings: []string{"soir"}}},
+	{Id: 29, Frequency: Frequency{Times: 5, Period: "jour"}},
+	{Id: 30, Frequency: Frequency{Times: 6, Period: "jour"}},
+	{Id: 31, Frequency: Frequency{IntervalMin: 1, IntervalUnit: "h"}},
+	{Id: 32, Frequency: Frequency{IntervalMin: 2, IntervalUnit: "h"}},
+	{Id: 33, Frequency: Frequency{IntervalMin: 6, IntervalMax: 8, IntervalUnit: "h"}},
+	{Id: 34, Frequency: Frequency{IntervalMin: 8, IntervalMax: 12, IntervalUnit: "h"}},
+	{Id: 35, Frequency: Frequency{IntervalMin: 48, IntervalUnit: "h"}},
+	{Id: 36, Frequency: Frequency{IntervalMin: 2, IntervalUnit: "jour"}},
+	{Id: 37, Frequency: Frequency{IntervalMin: 3, IntervalUnit: "jour"}},
+	{Id: 38, Frequency: Frequency{IntervalMin: 7, IntervalUnit: "jour"}},
+	{Id: 39, Frequency: Frequency{IntervalMin: 14, IntervalUnit: "jour"}},
+	{Id: 40, Frequency: Frequency{IntervalMin: 21, IntervalUnit: "jour"}},
+	{Id: 41, Frequency: Frequency{IntervalMin: 28, IntervalUnit: "jour"}},
+	{Id: 42, Frequency: Frequency{IntervalMin: 30, IntervalUnit: "jour"}},
+	{Id: 43, Frequency: Frequency{IntervalMin: 3, IntervalUnit: "mois"}},
+	{Id: 44, Frequency: Frequency{IntervalMin: 6, IntervalUnit: "mois"}},
+}
+
+func DefaultFrequencyCatalog() FrequencyCatalog {
+	var catalog FrequencyCatalog
+	for _, entry := range defaultFrequencies {
+		entry.Label = entry.Frequency.Label()
+		catalog = append(catalog, entry)
+	}
+
+	for _, entry := range defaultFrequencies {
+		entry.Id += 100
+		entry.Frequency.Prn = true
+		entry.Label = entry.Frequency.Label()
+		catalog = append(catalog, entry)
+	}
+
+	return catalog
+}
+
+// LoadFrequencyCatalog lit un catalogue en JSON (tableau de CatalogEntry) ou
+// en CSV avec l'en-tête suivant :
+//
+//	id,label,once,times,period,interval_min,interval_max,interval_unit,event,timings,prn
+//
+// Les moments (timings) sont séparés par « | ». Une entrée sans définition
+// structurée est associée par son libellé.
+func LoadFrequencyCatalog(path string) (FrequencyCatalog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var catalog FrequencyCatalog
+		if err := json.NewDecoder(file).Decode(&catalog); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return catalog, nil
+	}
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: catalogue vide", path)
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, fmt.Errorf("%s: colonne id manquante", path)
+	}
+
+	var catalog FrequencyCatalog
+	for i, record := range records[1:] {
+		value := func(name string) string {
+			if column, ok := columns[name]; ok && column < len(record) {
+				return strings.TrimSpace(record[column])
+			}
+			return ""
+		}
+		number := func(name string) (int, error) {
+			if value(name) == "" {
+				return 0, nil
+			}
+			return strconv.Atoi(value(name))
+		}
+
+		entry := CatalogEntry{Label: value("label")}
+		if entry.Id, err = strconv.Atoi(value("id")); err != nil {
+			return nil, fmt.Errorf("%s: ligne %d: id invalide: %w", path, i+2, err)
+		}
+		if entry.Frequency.Times, err = number("times"); err != nil {
+			return nil, fmt.Errorf("%s: ligne %d: times invalide: %w", path, i+2, err)
+		}
+		if entry.Frequency.IntervalMin, err = number("interval_min"); err != nil {
+			return nil, fmt.Errorf("%s: ligne %d: interval_min invalide: %w", path, i+2, err)
+		}
+		if entry.Frequency.IntervalMax, err = number("interval_max"); err != nil {
+			return nil, fmt.Errorf("%s: ligne %d: interval_max invalide: %w", path, i+2, err)
+		}
+		entry.Frequency.Once = isTrue(value("once"))
+		entry.Frequency.Period = value("period")
+		entry.Frequency.IntervalUnit = value("interval_unit")
+		entry.Frequency.Event = value("event")
+		if timings := value("timings"); timings != "" {
+			entry.Frequency.Timings = strings.Split(timings, "|")
+		}
+		entry.Frequency.Prn = isTrue(value("prn"))
+
+		catalog = append(catalog, entry)
+	}
+
+	return catalog, nil
+}
+
+func isTrue(value string) bool {
+	return slices.Contains([]string{"1", "true", "oui", "yes", "x"}, strings.ToLower(value))
+}
@@ -0,0 +1,187 @@
+package poso
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFrequencyCatalogLookup(t *testing.T) {
+	catalog := DefaultFrequencyCatalog()
+
+	testCases := []struct {
+		input         Frequency
+		expectedId    int
+		expectedFound bool
+	}{
+		{
+			input:         Frequency{},
+			expectedId:    0,
+			expectedFound: false,
+		},
+		{
+			input:         Frequency{Times: 1, Period: "jour"},
+			expectedId:    1,
+			expectedFound: true,
+		},
+		{
+			input:         Frequency{Times: 1, Period: "jour", Timings: []string{"coucher"}, Prn: true},
+			expectedId:    107,
+			expectedFound: true,
+		},
+		{
+			input:         Frequency{IntervalMin: 4, IntervalMax: 6, IntervalUnit: "h", Prn: true},
+			expectedId:    114,
+			expectedFound: true,
+		},
+		{
+			input:         Frequency{Times: 7, Period: "jour"},
+			expectedId:    0,
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestFrequencyCatalogLookup", func(t *testing.T) {
+			actualId, actualFound := catalog.Lookup(tc.input)
+			if actualId != tc.expectedId || actualFound != tc.expectedFound {
+				t.Errorf("I: %v\nE: %v %v\nA: %v %v", tc.input, tc.expectedId, tc.expectedFound, actualId, actualFound)
+				return
+			}
+		})
+	}
+}
+
+func TestDefaultFrequencyCatalogIds(t *testing.T) {
+	ids := map[int]bool{}
+	labels := map[string]int{}
+	for _, entry := range DefaultFrequencyCatalog() {
+		if ids[entry.Id] {
+			t.Errorf("Duplicate id %d (%s)", entry.Id, entry.Label)
+		}
+		ids[entry.Id] = true
+
+		// ex: q4h (13) et q4-6h (14)
+		if id, ok := labels[entry.Label]; ok {
+			t.Errorf("Duplicate label %s (%d, %d)", entry.Label, id, entry.Id)
+		}
+		labels[entry.Label] = entry.Id
+	}
+}
+
+// Chaque fréquence produite par le parser sur les corpus a un identifiant
+// dans le catalogue par défaut, y compris celles des étapes
+func TestDefaultFrequencyCatalogCoverage(t *testing.T) {
+	catalog := DefaultFrequencyCatalog()
+	parser := NewParser()
+
+	for _, path := range []string{"../in_sample.txt", "../translate/testdata/in.txt"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		missing := map[string]string{}
+		for _, line := range strings.Split(string(data), "\n") {
+			dosage, _ := parser.Parse(line)
+
+			frequencies := []Frequency{dosage.FrequencyDetail}
+			for _, step := range dosage.Steps {
+				frequencies = append(frequencies, step.FrequencyDetail)
+			}
+			for _, frequency := range frequencies {
+				if _, found := catalog.Lookup(frequency); !found && frequency.Label() != "" {
+					missing[frequency.Label()] = line
+				}
+			}
+		}
+
+		for label, line := range missing {
+			t.Errorf("I: %v\nE: un identifiant pour %q\nA: 0", line, label)
+		}
+	}
+}
+
+func TestLoadFrequencyCatalog(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "catalog.csv")
+	csvData := "id,label,once,times,period,interval_min,interval_max,interval_unit,event,timings,prn\n" +
+		"501,DIE,,1,jour,,,,,,\n" +
+		"502,BID REPAS,,2,jour,,,,,déjeuner|souper,\n" +
+		"503,Q4-6H PRN,,,,4,6,h,,,oui\n" +
+		"504,1 fois par semaine,,,,,,,,,\n"
+	if err := os.WriteFile(csvPath, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(dir, "catalog.json")
+	jsonData := `[{"id": 601, "label": "DIE", "frequency": {"times": 1, "period": "jour"}}]`
+	if err := os.WriteFile(jsonPath, []byte(jsonData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path       string
+		input      Frequency
+		expectedId int
+	}{
+		{
+			path:       csvPath,
+			input:      Frequency{Times: 1, Period: "jour"},
+			expectedId: 501,
+		},
+		{
+			path:       csvPath,
+			input:      Frequency{Times: 2, Period: "jour", Timings: []string{"déjeuner", "souper"}},
+			expectedId: 502,
+		},
+		{
+			path:       csvPath,
+			input:      Frequency{IntervalMin: 4, IntervalMax: 6, IntervalUnit: "h", Prn: true},
+			expectedId: 503,
+		},
+		{
+			path:       csvPath,
+			input:      Frequency{Times: 1, Period: "semaine"},
+			expectedId: 504,
+		},
+		{
+			path:       csvPath,
+			input:      Frequency{Times: 3, Period: "jour"},
+			expectedId: 0,
+		},
+		{
+			path:       jsonPath,
+			input:      Frequency{Times: 1, Period: "jour"},
+			expectedId: 601,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestLoadFrequencyCatalog", func(t *testing.T) {
+			catalog, err := LoadFrequencyCatalog(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actualId, _ := catalog.Lookup(tc.input)
+			if actualId != tc.expectedId {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expectedId, actualId)
+				return
+			}
+		})
+	}
+}
+
+func TestLoadFrequencyCatalogInvalidId(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.csv")
+	if err := os.WriteFile(path, []byte("id,label\nabc,DIE\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFrequencyCatalog(path); err == nil {
+		t.Errorf("Expected an error for an invalid id")
+	}
+}
@@ -0,0 +1,831 @@
+package poso
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type Dosage struct {
+	Id              int        `json:"id"`
+	SourceId        string     `json:"source_id,omitempty"`
+	Text            string     `json:"text"`
+	Dose            string     `json:"dose"`
+	DoseMin         float64    `json:"dose_min"`
+	DoseMax         float64    `json:"dose_max"`
+	DoseUnit        string     `json:"dose_unit"`
+	Route           string     `json:"route"`
+	FrequencyId     int        `json:"frequency_id"`
+	Frequency       string     `json:"frequency"`
+	FrequencyDetail Frequency  `json:"frequency_detail"`
+	Duration        Duration   `json:"duration"`
+	MaxDoses        []MaxDose  `json:"max_doses,omitempty"`
+	Prn             bool       `json:"prn"`
+	PrnReason       string     `json:"prn_reason"`
+	Indication      Indication `json:"indication"`
+	Steps           []Step     `json:"steps,omitempty"`
+	Spans           Spans      `json:"spans"`
+
+	// Règles qui ont produit chaque champ, si Parser.Explain est activé
+	Trace []RuleMatch `json:"trace,omitempty"`
+
+	// Colonnes du fichier d'entrée recopiées telles quelles (nom → valeur)
+	Columns map[string]string `json:"columns,omitempty"`
+}
+
+// Step représente une étape d'une posologie à plusieurs étapes (dose de
+// charge, sevrage, etc.).
+type Step struct {
+	Dose            string    `json:"dose"`
+	DoseMin         float64   `json:"dose_min"`
+	DoseMax         float64   `json:"dose_max"`
+	DoseUnit        string    `json:"dose_unit"`
+	Frequency       string    `json:"frequency"`
+	FrequencyDetail Frequency `json:"frequency_detail"`
+	Duration        Duration  `json:"duration"`
+	Spans           Spans     `json:"spans"`
+}
+
+// MapAll analyse une posologie avec le Parser par défaut
+func MapAll(line string) (Dosage, error) {
+	return defaultParser.Parse(line)
+}
+
+// Les groupes dose et dose_unit donnent la position de la dose et de son
+// unité dans le texte (Dosage.Spans)
+var (
+	doseTabletRule       = newRule("dose.comprimé", `(?P<dose>(((\d(\.|,))?\d+)( A |\-| \- | TO ))?((\d(\.|,))?\d+)) (?P<dose_unit>COMPRIMES|COMPRIME|TABLETS|TABLET|TABS|TAB\b|PILLS?)`)
+	doseCapsuleRule      = newRule("dose.capsule", `(?P<dose>((\d+)( A |\-| \- | TO ))?((\d(\.|,))?\d+)) (?P<dose_unit>CAPSULES|CAPSULE)`)
+	doseOneCapsuleRule   = newRule("dose.une-capsule", `(?P<dose>LA|UNE) (?P<dose_unit>CAPSULE)`)
+	doseSprayRule        = newRule("dose.vaporisation", `(?P<dose>[0-9]+) (?P<dose_unit>VAPORISATIONS|VAPORISATION|SPRAYS|SPRAY)`)
+	doseSprayTimesRule   = newRule("dose.vaporiser-fois", `(?P<dose_unit>VAPORISE(?:R|Z)) (?P<dose>[0-9]+) FOIS`)
+	doseSprayVerbRule    = newRule("dose.vaporiser", `(?P<dose_unit>VAPORISER|VAPORISEZ)`)
+	dosePuffRule         = newRule("dose.bouffée", `(?P<dose>[0-9]+) (?P<dose_unit>INHALATIONS|INHALATION|PUFFS|PUFF|BOUFFEES|BOUFFEE)`)
+	doseDropRule         = newRule("dose.goutte", `(?P<dose>[0-9]+) (?P<dose_unit>GOUTTES?|DROPS?|G )`)
+	doseGramRule         = newRule("dose.gramme", `(?P<dose>[0-9]+) (?P<dose_unit>GRAMMES?|GRAMS?|G )`)
+	doseGramAttachedRule = newRule("dose.gramme-attaché", `(?P<dose>[0-9]+)(?P<dose_unit>GRAMMES?|G|G\. )`)
+	dosePatchRule        = newRule("dose.timbre", `(?P<dose>[0-9]+|UN) (?P<dose_unit>TIMBRES?|PATCHS?|PATCHES)`)
+	doseSuppositoryRule  = newRule("dose.suppositoire", `(?P<dose>[0-9]+) (?P<dose_unit>SUPPOSITOIRES?|SUPPOSITORY|SUPPOSITORIES)`)
+	dosePacketRule       = newRule("dose.sachet", `(?P<dose>[0-9]+) (?P<dose_unit>SACHETS?|PACKETS?)`)
+	doseApplicationRule  = newRule("dose.application", `(?P<dose>[0-9]+) (?P<dose_unit>APPLICATIONS?)`)
+	doseMeasureRule      = newRule("dose.mesure", `(?P<dose>(?:[0-9]+[.,])?[0-9]+(?:(?: A | TO |-| - )(?:[0-9]+[.,])?[0-9]+)?) ?(?P<dose_unit>MLS?|MG|MCG|UNITES?|UNITS?)\b`)
+)
+
+func MapDose(line string) (string, string) {
+	return mapDose(line, nil)
+}
+
+func mapDose(line string, t *trace) (string, string) {
+	line = maskMaxDoses(line)
+
+	if isComplexDosage(line) {
+		return "", ""
+	}
+
+	return findDose(line, t)
+}
+
+// findDose retourne la première dose reconnue dans line, qu'elle décrive une
+// posologie simple ou une étape
+func findDose(line string, t *trace) (string, string) {
+	line = RemoveFraction(line)
+	line = RemoveNumberWords(line)
+
+	if match := doseTabletRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseTabletRule, line)
+		return cleanDose(match[1]), "comprimé"
+	}
+
+	if match := doseCapsuleRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseCapsuleRule, line)
+		return cleanDose(match[1]), "capsule"
+	}
+
+	if doseOneCapsuleRule.MatchString(line) {
+		t.rule(doseOneCapsuleRule, line)
+		return "1", "capsule"
+	}
+
+	if match := doseSprayRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseSprayRule, line)
+		return match[1], "vaporisation"
+	}
+
+	if match := doseSprayTimesRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseSprayTimesRule, line)
+		return match[2], "vaporisation"
+	}
+
+	if doseSprayVerbRule.MatchString(line) {
+		t.rule(doseSprayVerbRule, line)
+		return "1", "vaporisation"
+	}
+
+	if match := dosePuffRule.FindStringSubmatch(line); match != nil {
+		t.rule(dosePuffRule, line)
+		return match[1], "bouffée"
+	}
+
+	if match := doseDropRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseDropRule, line)
+		return match[1], "goutte"
+	}
+
+	if match := doseGramRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseGramRule, line)
+		return match[1], "g"
+	}
+
+	if match := doseGramAttachedRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseGramAttachedRule, line)
+		return match[1], "g"
+	}
+
+	if match := dosePatchRule.FindStringSubmatch(line); match != nil {
+		t.rule(dosePatchRule, line)
+		if match[1] == "UN" {
+			return "1", "timbre"
+		}
+		return match[1], "timbre"
+	}
+
+	if match := doseSuppositoryRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseSuppositoryRule, line)
+		return match[1], "suppositoire"
+	}
+
+	if match := dosePacketRule.FindStringSubmatch(line); match != nil {
+		t.rule(dosePacketRule, line)
+		return match[1], "sachet"
+	}
+
+	if match := doseApplicationRule.FindStringSubmatch(line); match != nil {
+		t.rule(doseApplicationRule, line)
+		return match[1], "application"
+	}
+
+	// ML, MG, UNITES (mais pas MAXIMUM # MG)
+	if match := doseMeasureRule.FindStringSubmatchIndex(line); match != nil {
+		if !strings.Contains(line[:match[0]], "MAX") && !strings.Contains(line[:match[0]], "EXCEED") {
+			t.rule(doseMeasureRule, line)
+			return cleanDose(line[match[2]:match[3]]), doseUnits[line[match[4]:match[5]]]
+		}
+	}
+
+	return "", ""
+}
+
+var doseUnits = map[string]string{
+	"ML":     "ml",
+	"MLS":    "ml",
+	"MG":     "mg",
+	"MCG":    "mcg",
+	"UNITE":  "unité",
+	"UNITES": "unité",
+	"UNIT":   "unité",
+	"UNITS":  "unité",
+}
+
+// cleanDose normalise une dose (ex: "1 A 2" → "1-2", "0,5" → "0.5")
+func cleanDose(dose string) string {
+	dose = strings.Replace(dose, "TO", "-", -1)
+	dose = strings.Replace(dose, "A", "-", -1)
+	dose = strings.Replace(dose, " ", "", -1)
+	dose = strings.Replace(dose, ",", ".", -1)
+	return dose
+}
+
+// ParseDoseRange retourne les bornes numériques d'une dose retournée par
+// MapDose ("1" → 1, 1 ; "0.5-2" → 0.5, 2).
+func ParseDoseRange(dose string) (float64, float64) {
+	if dose == "" {
+		return 0, 0
+	}
+
+	low, high, found := strings.Cut(dose, "-")
+	if !found {
+		return parseNumber(low), parseNumber(low)
+	}
+	return parseNumber(low), parseNumber(high)
+}
+
+var (
+	stepsNowAndRule    = newRule("étapes.maintenant-et", `(MAINTENANT|IMMEDIATEMENT|IMMEDIATELY) ET `)
+	stepsSeparatorRule = newRule("étapes.séparateur", `,?\s+(?:PUIS|(?:AND )?THEN)\s+`)
+)
+
+func MapSteps(line string) []Step {
+	return mapSteps(line, indications, nil)
+}
+
+// lexicon est le lexique des indications, pour la raison d'un PRN
+func mapSteps(line string, lexicon map[string]string, t *trace) []Step {
+	line = maskMaxDoses(line)
+	if !isComplexDosage(line) {
+		return nil
+	}
+
+	// "2 COMPRIMES MAINTENANT ET 1 COMPRIME..." : le ET sépare deux étapes
+	line = stepsNowAndRule.ReplaceAllString(line, "$1 PUIS ")
+
+	var steps []Step
+	for i, segment := range stepsSeparatorRule.Split(line, -1) {
+		step := Step{}
+		t.segment(segment)
+		continued := i > 0 && continuesStep(segment)
+		t.enter(fmt.Sprintf("steps[%d].dose", i))
+		if continued {
+			// "PUIS 2 FOIS PAR SEMAINE", "PUIS AUGMENTER DE 0.6 MG" : même dose
+			t.deduction("étapes.dose-précédente")
+			step.Dose, step.DoseUnit = steps[i-1].Dose, steps[i-1].DoseUnit
+		} else {
+			step.Dose, step.DoseUnit = mapDose(segment, t)
+		}
+		step.DoseMin, step.DoseMax = ParseDoseRange(step.Dose)
+		t.enter(fmt.Sprintf("steps[%d].frequency", i))
+		step.FrequencyDetail = mapFrequency(segment, lexicon, t)
+		if continued && step.FrequencyDetail.IsZero() {
+			t.deduction("étapes.fréquence-précédente")
+			step.FrequencyDetail = steps[i-1].FrequencyDetail
+		}
+		step.Frequency = step.FrequencyDetail.Label()
+		t.enter(fmt.Sprintf("steps[%d].duration", i))
+		step.Duration = mapDuration(segment, t)
+
+		steps = append(steps, step)
+	}
+	t.segment("")
+
+	return steps
+}
+
+var (
+	routeNasalRule         = newRule("voie.nasale", `CHAQUE NARINE|DANS LES NARINES|NOSTRILS?|INTO (THE )?NOSE|NASAL|\bNARE\b`)
+	routeIntramuscularRule = newRule("voie.intramusculaire", `INTRA(-|\s)?MUSCULAIRE|INTRAMUSCULAR(LY)?|INTO (THE )?MUSCLE`)
+	routeSubcutaneousRule  = newRule("voie.sous-cutané", `SOUS(-|\s)?CUTANEE|SOUS LA PEAU|UNDER THE SKIN|SUBCUTANEOUS(LY)?`)
+	routeIntravenousRule   = newRule("voie.intraveineux", `INTRAVEINEU(X|SE)|INTRAVENOUS(LY)?`)
+	routeEyeRule           = newRule("voie.oculaire", `OEIL|ŒIL|YEUX|\bEYES?\b|\bEYE\(S\)`)
+	routeLeftRule          = newRule("voie.gauche", `GAUCHE|LEFT`)
+	routeRightRule         = newRule("voie.droit", `DROIT|RIGHT`)
+	routeBothEyesRule      = newRule("voie.2-yeux", `YEUX|BOTH EYES|EACH EYE`)
+	routeEarRule           = newRule("voie.otique", `OREILLES?|\bEARS?\b|\bEAR\(S\)`)
+	routeBothEarsRule      = newRule("voie.2-oreilles", `OREILLES|BOTH EARS|EACH EAR`)
+	routeRectalRule        = newRule("voie.rectal", `RECTUM|RECTAL(EMENT|LY)?|SUPPOSITOIRE|SUPPOSITOR(Y|IES)`)
+	routeVaginalRule       = newRule("voie.vaginal", `VAGIN(AL|ALLY|ALE)?\b|VAGINA\b`)
+	routeTopicalRule       = newRule("voie.topique", `APPLIQUE(R|Z)|APPLICATION LOCALE|\bAPPLY\b|TOPICAL(LY)?|LOCALEMENT|(ONTO|TO) (THE )?SKIN|TO (THE )?AFFECTED AREA`)
+	routeDissolveRule      = newRule("voie.oral-dissoudre", `BOIRE|DISSOU.*\sVERRE D'EAU`)
+	routeSublingualRule    = newRule("voie.sublingual", `SOUS LA LANGUE|UNDER THE TONGUE|SUBLINGUAL(LY)?`)
+	routeInhalationRule    = newRule("voie.inhalation", `INHALE(R|Z) (LE CONTENU D'UNE )?CAPSULE|\bINHALE\b|NEBULI(SATION|ZATION|ZER)|INTO THE LUNGS`)
+	routeOralRule          = newRule("voie.oral", `PAR LA BOUCHE|PER OS|BY MOUTH|ORALLY|\bPO\b`)
+)
+
+func MapRoute(line string, dosage Dosage) string {
+	return mapRoute(line, dosage, nil)
+}
+
+func mapRoute(line string, dosage Dosage, t *trace) string {
+
+	if routeNasalRule.MatchString(line) {
+		t.rule(routeNasalRule, line)
+		return "nasale"
+	}
+
+	if routeIntramuscularRule.MatchString(line) {
+		t.rule(routeIntramuscularRule, line)
+		return "intramusculaire"
+	}
+
+	if routeSubcutaneousRule.MatchString(line) {
+		t.rule(routeSubcutaneousRule, line)
+		return "sous-cutané"
+	}
+
+	if routeIntravenousRule.MatchString(line) {
+		t.rule(routeIntravenousRule, line)
+		return "intraveineux"
+	}
+
+	if routeEyeRule.MatchString(line) {
+		t.rule(routeEyeRule, line)
+		if routeLeftRule.MatchString(line) {
+			t.rule(routeLeftRule, line)
+			return "oeil gauche"
+		} else if routeRightRule.MatchString(line) {
+			t.rule(routeRightRule, line)
+			return "oeil droit"
+		} else if routeBothEyesRule.MatchString(line) {
+			t.rule(routeBothEyesRule, line)
+			return "dans les 2 yeux"
+		}
+		return "oculaire"
+	}
+
+	if routeEarRule.MatchString(line) {
+		t.rule(routeEarRule, line)
+		if routeLeftRule.MatchString(line) {
+			t.rule(routeLeftRule, line)
+			return "oreille gauche"
+		} else if routeRightRule.MatchString(line) {
+			t.rule(routeRightRule, line)
+			return "oreille droit"
+		} else if routeBothEarsRule.MatchString(line) {
+			t.rule(routeBothEarsRule, line)
+			return "dans les 2 oreilles"
+		}
+		return "otique"
+	}
+
+	if routeRectalRule.MatchString(line) {
+		t.rule(routeRectalRule, line)
+		return "rectal"
+	}
+
+	if routeVaginalRule.MatchString(line) {
+		t.rule(routeVaginalRule, line)
+		return "vaginal"
+	}
+
+	if routeTopicalRule.MatchString(line) {
+		t.rule(routeTopicalRule, line)
+		return "topique"
+	}
+
+	if routeDissolveRule.MatchString(line) {
+		t.rule(routeDissolveRule, line)
+		return "oral"
+	}
+
+	if routeSublingualRule.MatchString(line) {
+		t.rule(routeSublingualRule, line)
+		return "sublingual"
+	}
+
+	if routeInhalationRule.MatchString(line) {
+		t.rule(routeInhalationRule, line)
+		return "inhalation"
+	}
+
+	// Une bouffée prise « par la bouche » est inhalée
+	if dosage.DoseUnit == "bouffée" {
+		t.deduction("voie.unité-de-dose")
+		return "inhalation"
+	}
+
+	if routeOralRule.MatchString(line) {
+		t.rule(routeOralRule, line)
+		return "oral"
+	}
+
+	// Voie déduite de l'unité de dose
+	if slices.Contains([]string{"comprimé", "capsule"}, dosage.DoseUnit) {
+		t.deduction("voie.unité-de-dose")
+		return "oral"
+	} else if dosage.DoseUnit == "timbre" {
+		t.deduction("voie.unité-de-dose")
+		return "topique"
+	}
+
+	return ""
+}
+
+var (
+	frequencyMaxDailyRule       = newRule("fréquence.max-quotidien", `MAX(IMUM)? DAILY (AMOUNT|DOSE)`)
+	frequencyWithFoodRule       = newRule("fréquence.en-mangeant", `EN MANGEANT|AVEC NOURRITURE|WITH (FOOD|MEALS)`)
+	frequencyTimesPerDayRule    = newRule("fréquence.fois-par-jour", `([0-9]+|UNE) FOIS PAR JOUR`)
+	frequencyTimesDailyRule     = newRule("fréquence.times-daily", `\b(?:([0-9]+) TIMES?|ONCE|TWICE) (?:DAILY|A DAY|PER DAY|EACH DAY|EVERY DAY)\b|\b(BID|TID|QID|QD|DIE)\b`)
+	frequencyHoursRule          = newRule("fréquence.heures", `(?:AUX|TOU(?:TE)?S LES|EVERY) ([0-9]+)(?:(?: A | TO |-| - )([0-9]+))?\s*(?:HEURES|HOURS?|HRS?)\b|\bQ ?([0-9]+)(?:-([0-9]+))? ?(?:H|HRS?|HOURS?)\b`)
+	frequencyEveryHourRule      = newRule("fréquence.chaque-heure", `EVERY HOUR\b|TOUTES LES HEURES|CHAQUE HEURE`)
+	frequencyMinutesRule        = newRule("fréquence.minutes", `(?:AUX|TOUTES LES|EVERY) ([0-9]+)(?:(?: A | TO |-)([0-9]+))?\s*MIN(?:UTES?|S)?\b`)
+	frequencyDaysRule           = newRule("fréquence.jours", `(?:AUX|TOU(?:TE)?S LES|A TOU(?:TE)?S LES|EVERY) ([0-9]+) (JOURS|DAYS|SEMAINES|WEEKS|MOIS|MONTHS)\b`)
+	frequencyOtherDayRule       = newRule("fréquence.jour-sur-deux", `EVERY (OTHER|SECOND) DAY|UN JOUR SUR DEUX`)
+	frequencyThirdDayRule       = newRule("fréquence.jour-sur-trois", `EVERY THIRD DAY`)
+	frequencyWeeklyRule         = newRule("fréquence.semaine", `([0-9]+) FOIS PAR SEMAINE|\b([0-9]+ TIMES?|ONCE|TWICE) (?:A|PER|EACH) WEEK\b|\b(ONCE |TWICE )?WEEKLY\b|EVERY WEEK\b|CHAQUE SEMAINE`)
+	frequencyDailyRule          = newRule("fréquence.daily", `\bDAILY\b|\bEVERY DAY\b|\bQ ?DAY\b`)
+	frequencyUnitsPerDayRule    = newRule("fréquence.unités-par-jour", `[0-9]+(?:[.,][0-9]+)? ?(?:COMPRIMES?|CAPSULES?|TABLETS?|MG|MCG|ML|G|UNITES?|UNITS?) (?:PAR JOUR|PER DAY)\b`)
+	frequencyUnitsPerWeekRule   = newRule("fréquence.unités-par-semaine", `[0-9]+ (COMPRIMES?|CAPSULES?|TIMBRES?) PAR SEMAINE`)
+	frequencyMorningEveningRule = newRule("fréquence.matin-soir", `(LE MATIN|EVERY MORNING|IN THE MORNING)\b.*\b(?:(?:ET|AND) |[0-9]+ [A-Z]+ (?:[A-Z]+ )?(?:AS NEEDED )?)+(LE SOIR|EVERY EVENING|IN THE EVENING)`)
+	frequencyMorningRule        = newRule("fréquence.matin", `LE MATIN|EVERY MORNING|IN THE MORNING|\bQ ?AM\b|\bIN AM\b`)
+	frequencyBedtimeRule        = newRule("fréquence.coucher", `(30 MINUTES|1/2 HEURE) AVANT LE COUCHER|AU COUCHER|NIGHTLY|BEDTIME|\bQHS\b`)
+	frequencyEveningRule        = newRule("fréquence.soir", `LE SOIR|CHAQUE SOIR|EVERY EVENING|IN THE EVENING|EVERY NIGHT`)
+	frequencyStoolRule          = newRule("fréquence.selle", `APRES CHAQUE SELLE|AFTER EACH (LOOSE )?STOOL`)
+	frequencyOnceRule           = newRule("fréquence.une-fois", `UNE SEULE DOSE|DOSE UNIQUE|UNE SEULE PRISE|IMMEDIATEMENT|IMMEDIATELY|MAINTENANT|LE (1ER|PREMIER) JOUR|ON THE FIRST DAY|SINGLE DOSE|\bONCE\b|\b1 TIME\b|\bSTAT\b`)
+	frequencyPatchRule          = newRule("fréquence.timbre-24h", `GARDER 24 HEURES,? ?RETIRER .* CHANGER`)
+)
+
+func MapFrequency(line string) Frequency {
+	return mapFrequency(line, indications, nil)
+}
+
+// mapFrequency détermine le PRN avec le même lexique que mapPrn, pour que le
+// libellé (ex: "q4h PRN") concorde avec Dosage.Prn
+func mapFrequency(line string, lexicon map[string]string, t *trace) Frequency {
+	frequency := Frequency{}
+	withFood := false
+
+	line = maskMaxDoses(line)
+	if isComplexDosage(line) {
+		return frequency
+	}
+
+	frequency.Prn, _ = mapPrn(line, lexicon, nil)
+
+	line = RemoveNumberWords(line)
+
+	// La dose maximale quotidienne n'est pas une fréquence
+	line = frequencyMaxDailyRule.ReplaceAllString(line, "MAX")
+
+	if frequencyWithFoodRule.MatchString(line) {
+		withFood = true
+	}
+
+	// # FOIS PAR JOUR (FR)
+	if match := frequencyTimesPerDayRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyTimesPerDayRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		if freq := match[1]; freq != "UNE" {
+			frequency.Times, _ = strconv.Atoi(freq)
+		}
+		frequency.Timings = mapTimings(line, frequency.Times, withFood, t)
+		return frequency
+	}
+
+	// # FOIS PAR JOUR (EN)
+	if match := frequencyTimesDailyRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyTimesDailyRule, line)
+
+		frequency.Period = "jour"
+		if match[1] != "" {
+			frequency.Times, _ = strconv.Atoi(match[1])
+		} else if strings.HasPrefix(match[0], "TWICE") || match[2] == "BID" {
+			frequency.Times = 2
+		} else if match[2] == "TID" {
+			frequency.Times = 3
+		} else if match[2] == "QID" {
+			frequency.Times = 4
+		} else {
+			frequency.Times = 1
+		}
+		frequency.Timings = mapTimings(line, frequency.Times, withFood, t)
+		return frequency
+	}
+
+	// AUX # HEURES
+	if match := frequencyHoursRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyHoursRule, line)
+		frequency.IntervalMin, _ = strconv.Atoi(match[1] + match[3])
+		frequency.IntervalMax, _ = strconv.Atoi(match[2] + match[4])
+		frequency.IntervalUnit = "h"
+		return frequency
+	}
+
+	if frequencyEveryHourRule.MatchString(line) {
+		t.rule(frequencyEveryHourRule, line)
+		frequency.IntervalMin, frequency.IntervalUnit = 1, "h"
+		return frequency
+	}
+
+	// AUX # MINUTES
+	if match := frequencyMinutesRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyMinutesRule, line)
+		frequency.IntervalMin, _ = strconv.Atoi(match[1])
+		frequency.IntervalMax, _ = strconv.Atoi(match[2])
+		frequency.IntervalUnit = "min"
+		return frequency
+	}
+
+	// AUX # JOURS, SEMAINES, MOIS
+	if match := frequencyDaysRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyDaysRule, line)
+		frequency.IntervalMin, _ = strconv.Atoi(match[1])
+		frequency.IntervalUnit = durationUnit(match[2])
+		return frequency
+	}
+
+	if frequencyOtherDayRule.MatchString(line) {
+		t.rule(frequencyOtherDayRule, line)
+		frequency.IntervalMin, frequency.IntervalUnit = 2, "jour"
+		return frequency
+	}
+
+	if frequencyThirdDayRule.MatchString(line) {
+		t.rule(frequencyThirdDayRule, line)
+		frequency.IntervalMin, frequency.IntervalUnit = 3, "jour"
+		return frequency
+	}
+
+	// # FOIS PAR SEMAINE
+	if match := frequencyWeeklyRule.FindStringSubmatch(line); match != nil {
+		t.rule(frequencyWeeklyRule, line)
+		freqEn := strings.TrimSpace(match[2] + match[3])
+
+		frequency.Period = "semaine"
+		if freqEn == "TWICE" {
+			frequency.Times = 2
+		} else if freqEn == "ONCE" || (freqEn == "" && match[1] == "") {
+			frequency.Times = 1
+		} else {
+			frequency.Times, _ = strconv.Atoi(strings.Fields(match[1] + freqEn)[0])
+		}
+		return frequency
+	}
+
+	// DAILY, EVERY DAY (EN)
+	if frequencyDailyRule.MatchString(line) {
+		t.rule(frequencyDailyRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		frequency.Timings = mapTimings(line, 1, withFood, t)
+		return frequency
+	}
+
+	// # COMPRIMES OU # MG PAR JOUR (mais pas MAXIMUM # COMPRIMES PAR JOUR)
+	matches := frequencyUnitsPerDayRule.FindAllString(line, -1)
+
+	var filteredMatches []string
+	for _, match := range matches {
+		if !strings.Contains(line[:strings.Index(line, match)], "MAXIMUM") {
+			filteredMatches = append(filteredMatches, match)
+		}
+	}
+	if filteredMatches != nil {
+		t.ruleMatch(frequencyUnitsPerDayRule, filteredMatches[0])
+		frequency.Times, frequency.Period = 1, "jour"
+		frequency.Timings = mapTimings(line, 1, withFood, t)
+		return frequency
+	}
+
+	// PAR SEMAINE (mais pas MAXIMUM # COMPRIMES PAR SEMAINE)
+	matches = frequencyUnitsPerWeekRule.FindAllString(line, -1)
+
+	filteredMatches = nil
+	for _, match := range matches {
+		if !strings.Contains(line[:strings.Index(line, match)], "MAXIMUM") {
+			filteredMatches = append(filteredMatches, match)
+		}
+	}
+	if filteredMatches != nil {
+		t.ruleMatch(frequencyUnitsPerWeekRule, filteredMatches[0])
+		frequency.Times, frequency.Period = 1, "semaine"
+		return frequency
+	}
+
+	// MATIN ET SOIR, seulement si le soir est coordonné au matin ou précédé
+	// d'une dose (pas « LE MATIN, GARDER 12 HEURES ET RETIRER LE SOIR »)
+	if frequencyMorningEveningRule.MatchString(line) {
+		t.rule(frequencyMorningEveningRule, line)
+		frequency.Times, frequency.Period = 2, "jour"
+		frequency.Timings = mapTimings(line, 2, withFood, t)
+		return frequency
+	}
+
+	if frequencyMorningRule.MatchString(line) {
+		t.rule(frequencyMorningRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		frequency.Timings = []string{"matin"}
+		return frequency
+	}
+
+	if frequencyBedtimeRule.MatchString(line) {
+		t.rule(frequencyBedtimeRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		frequency.Timings = []string{"coucher"}
+		return frequency
+	}
+
+	if frequencyEveningRule.MatchString(line) {
+		t.rule(frequencyEveningRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		frequency.Timings = []string{"soir"}
+		return frequency
+	}
+
+	if frequencyStoolRule.MatchString(line) {
+		t.rule(frequencyStoolRule, line)
+		frequency.Event = "selle"
+		return frequency
+	}
+
+	if frequencyOnceRule.MatchString(line) {
+		t.rule(frequencyOnceRule, line)
+		frequency.Once = true
+		return frequency
+	}
+
+	if frequencyPatchRule.MatchString(line) {
+		t.rule(frequencyPatchRule, line)
+		frequency.Times, frequency.Period = 1, "jour"
+		return frequency
+	}
+
+	return frequency
+}
+
+var (
+	timingSupperRule          = newRule("moment.souper-anglais", `SUPPER|DINNER`)
+	timingBeforeBreakfastRule = newRule("moment.avant-déjeuner", `AVANT LE DEJEUNER|BEFORE BREAKFAST`)
+	timingBreakfastRule       = newRule("moment.déjeuner", `(AU|AVEC LE) DEJEUNER|BREAKFAST`)
+	timingMorningRule         = newRule("moment.matin", `LE MATIN|IN THE MORNING|EVERY MORNING`)
+	timingLunchRule           = newRule("moment.dîner", `AU DINER|WITH LUNCH`)
+	timingDinnerRule          = newRule("moment.souper", `AU SOUPER|WITH (SUPPER|DINNER)`)
+	timingBedtimeRule         = newRule("moment.coucher", `AVANT (LE )?COUCHER|AU COUCHER|AT BEDTIME|NIGHTLY`)
+	timingEveningRule         = newRule("moment.soir", `LE SOIR|IN THE EVENING|EVERY EVENING`)
+)
+
+// mapTimings retourne les moments de prise d'une posologie # fois par jour
+func mapTimings(line string, times int, withFood bool, t *trace) []string {
+	if times == 2 {
+		if strings.Contains(line, "DEJEUNER") && strings.Contains(line, "SOUPER") {
+			return []string{"déjeuner", "souper"}
+		} else if strings.Contains(line, "BREAKFAST") && timingSupperRule.MatchString(line) {
+			t.rule(timingSupperRule, line)
+			return []string{"déjeuner", "souper"}
+		} else if strings.Contains(line, "MATIN") && strings.Contains(line, "SOIR") && withFood {
+			t.rule(frequencyWithFoodRule, line)
+			return []string{"déjeuner", "souper"}
+		} else if strings.Contains(line, "MORNING") && strings.Contains(line, "EVENING") && withFood {
+			t.rule(frequencyWithFoodRule, line)
+			return []string{"déjeuner", "souper"}
+		}
+		return nil
+	}
+
+	if times != 1 {
+		return nil
+	}
+
+	if timingBeforeBreakfastRule.MatchString(line) {
+		t.rule(timingBeforeBreakfastRule, line)
+		return []string{"avant déjeuner"}
+	} else if timingBreakfastRule.MatchString(line) {
+		t.rule(timingBreakfastRule, line)
+		return []string{"déjeuner"}
+	} else if timingMorningRule.MatchString(line) {
+		t.rule(timingMorningRule, line)
+		return []string{"matin"}
+	} else if timingLunchRule.MatchString(line) {
+		t.rule(timingLunchRule, line)
+		return []string{"dîner"}
+	} else if timingDinnerRule.MatchString(line) {
+		t.rule(timingDinnerRule, line)
+		return []string{"souper"}
+	} else if timingBedtimeRule.MatchString(line) {
+		t.rule(timingBedtimeRule, line)
+		return []string{"coucher"}
+	} else if timingEveningRule.MatchString(line) {
+		t.rule(timingEveningRule, line)
+		return []string{"soir"}
+	}
+	return nil
+}
+
+func RemoveAccents(text string) (string, error) {
+	// Sans caractère accentué possible, le texte est inchangé
+	if isASCII(text) {
+		return text, nil
+	}
+
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, text)
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// isASCII indique si text ne contient que des caractères ASCII
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+var numberWords = map[string]string{
+	"ONE":        "1",
+	"TWO":        "2",
+	"THREE":      "3",
+	"FOUR":       "4",
+	"FIVE":       "5",
+	"SIX":        "6",
+	"SEVEN":      "7",
+	"EIGHT":      "8",
+	"NINE":       "9",
+	"TEN":        "10",
+	"TWELVE":     "12",
+	"FOURTEEN":   "14",
+	"FIFTEEN":    "15",
+	"TWENTY":     "20",
+	"TWENTYFOUR": "24",
+	"THIRTY":     "30",
+}
+
+var (
+	numberOneAndHalfRule  = newRule("nombre.un-et-demi", `\bONE AND (?:ONE|A) HALF\b`)
+	numberHalfRule        = newRule("nombre.demi", `\b(?:ONE-HALF|ONE HALF|HALF(?: A| OF A)?)\b`)
+	numberWordDigitsRule  = newRule("nombre.mot-chiffres", `\b([A-Z]+) \(([0-9]+)\)`)
+	numberDigitsWordRule  = newRule("nombre.chiffres-mot", `\b([0-9]+) \(([A-Z]+)\)`)
+	numberParenthesesRule = newRule("nombre.parenthèses", `(^|\s)\(([0-9]+)\)`)
+	numberWordRule        = newRule("nombre.mot", `\b[A-Z]+\b`)
+)
+
+// RemoveNumberWords remplace les nombres écrits en lettres (anglais) par des
+// chiffres : "ONE (1) TABLET" → "1 TABLET", "2 (TWO) TIMES" → "2 TIMES".
+func RemoveNumberWords(text string) string {
+	text = numberOneAndHalfRule.ReplaceAllString(text, "1.5")
+	text = numberHalfRule.ReplaceAllString(text, "0.5")
+	text = numberWordDigitsRule.ReplaceAllStringFunc(text, func(s string) string {
+		parts := strings.SplitN(s, " ", 2)
+		if _, ok := numberWords[parts[0]]; ok {
+			return strings.Trim(parts[1], "()")
+		}
+		return s
+	})
+	text = numberDigitsWordRule.ReplaceAllStringFunc(text, func(s string) string {
+		parts := strings.SplitN(s, " ", 2)
+		if _, ok := numberWords[strings.Trim(parts[1], "()")]; ok {
+			return parts[0]
+		}
+		return s
+	})
+	text = numberParenthesesRule.ReplaceAllString(text, "$1$2")
+	text = numberWordRule.ReplaceAllStringFunc(text, func(s string) string {
+		if number, ok := numberWords[s]; ok {
+			return number
+		}
+		return s
+	})
+	return text
+}
+
+func RemoveFraction(text string) string {
+	text = strings.Replace(text, "½", "1/2", -1)
+	text = strings.Replace(text, "¼", "1/4", -1)
+	text = strings.Replace(text, "¾", "3/4", -1)
+
+	if strings.Contains(text, "1 1/2") {
+		text = strings.Replace(text, "1 1/2", "1.5", -1)
+	} else if strings.Contains(text, "1 1/4") {
+		text = strings.Replace(text, "1 1/4", "1.25", -1)
+	} else if strings.Contains(text, "1/2") {
+		text = strings.Replace(text, "1/2", "0.5", -1)
+	} else if strings.Contains(text, "1/4") {
+		text = strings.Replace(text, "1/4", "0.25", -1)
+	} else if strings.Contains(text, "3/4") {
+		text = strings.Replace(text, "3/4", "0.75", -1)
+	}
+
+	return text
+}
+
+var (
+	complexStepDoseRule      = newRule("complexe.étape", `^(?:[A-Z]+ (?:A |TO )?)?[0-9]`)
+	complexStepFrequencyRule = newRule("complexe.étape-fréquence", `^(?:[0-9]+ (?:FOIS|TIMES?)\b|ONCE\b|TWICE\b|(?:AUX|TOU(?:TE)?S LES|EVERY) [0-9]+ |DAILY\b|WEEKLY\b)`)
+	complexStepAdjustRule    = newRule("complexe.étape-ajustement", `^(?:AUGMENTER|DIMINUER|INCREASE|DECREASE)(?: LA| THE)?(?: DOSE)? (?:DE|BY) [0-9]`)
+	complexNowStoolRule      = newRule("complexe.maintenant-selle", `MAINTENANT.*CHAQUE SELLE`)
+)
+
+// continuesStep indique si le segment d'une étape reprend la dose de l'étape
+// précédente : il commence par une fréquence ("2 FOIS PAR SEMAINE") ou par un
+// ajustement ("AUGMENTER DE 0.6 MG PAR SEMAINE")
+func continuesStep(segment string) bool {
+	segment = RemoveNumberWords(RemoveFraction(segment))
+	return complexStepFrequencyRule.MatchString(segment) || complexStepAdjustRule.MatchString(segment)
+}
+
+// isComplexDosage indique si la posologie comporte plusieurs étapes : une
+// dose, puis PUIS/THEN suivi d'une autre dose (ex: "40 MG ... PUIS 30 MG",
+// "THEN TAKE ONE CAPSULE", "PUIS AUGMENTER A 0.5 MG"), d'une fréquence ou d'un
+// ajustement de la dose ("PUIS 2 FOIS PAR SEMAINE"), mais pas "AGITER PUIS
+// VAPORISER 2 FOIS" ni "PUIS NETTOYER CHAQUE NARINE AVEC 120 ML"
+func isComplexDosage(line string) bool {
+	if complexNowStoolRule.MatchString(line) {
+		return true
+	}
+
+	if !stepsSeparatorRule.MatchString(line) {
+		return false
+	}
+	segments := stepsSeparatorRule.Split(line, -1)
+	if dose, _ := findDose(segments[0], nil); dose == "" {
+		return false
+	}
+	for _, segment := range segments[1:] {
+		if continuesStep(segment) {
+			return true
+		}
+		segment = RemoveNumberWords(RemoveFraction(segment))
+		if dose, _ := findDose(segment, nil); dose != "" && complexStepDoseRule.MatchString(segment) {
+			return true
+		}
+	}
+
+	return false
+}
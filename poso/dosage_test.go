@@ -0,0 +1,816 @@
+package poso
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMapDose(t *testing.T) {
+	testCases := []struct {
+		input            string
+		expectedDose     string
+		expectedDoseUnit string
+	}{
+		{
+			input:            "PRENDRE 1 COMPRIME",
+			expectedDose:     "1",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "SELON LES DIRECTIVES DU MEDECIN",
+			expectedDose:     "",
+			expectedDoseUnit: "",
+		},
+		{
+			input:            "2 VAPORISATIONS",
+			expectedDose:     "2",
+			expectedDoseUnit: "vaporisation",
+		},
+		{
+			input:            "PRENDRE 2 INHALATIONS",
+			expectedDose:     "2",
+			expectedDoseUnit: "bouffée",
+		},
+		{
+			input:            "PRENDRE 1 A 2 COMPRIMES",
+			expectedDose:     "1-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 1 - 2 COMPRIMES",
+			expectedDose:     "1-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 1-2 COMPRIMES",
+			expectedDose:     "1-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 17 GRAMMES",
+			expectedDose:     "17",
+			expectedDoseUnit: "g",
+		},
+		{
+			input:            "PRENDRE 0.5 COMPRIME",
+			expectedDose:     "0.5",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 0.5 A 1 COMPRIME",
+			expectedDose:     "0.5-1",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 0.5 COMPRIME",
+			expectedDose:     "0.5",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 0.5 A 2 COMPRIME",
+			expectedDose:     "0.5-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 0,5 A 2 COMPRIME",
+			expectedDose:     "0.5-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 0,5 COMPRIME",
+			expectedDose:     "0.5",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "1 COMPRIME FOIS PAR JOUR 1/2 HEURE AVANT COUCHER",
+			expectedDose:     "1",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "PRENDRE 2 COMPRIMES LE 1ER JOUR, PUIS 1 COMPRIME 1 FOIS PAR JOUR AUX 24 HEURES DU 2IÈME AU 5IÈME JOUR",
+			expectedDose:     "",
+			expectedDoseUnit: "",
+		},
+		{
+			input:            "VAPORISEZ 2 FOIS DANS LES NARINES LE MATIN - REGULIEREMENT (ALLERGIE)",
+			expectedDose:     "2",
+			expectedDoseUnit: "vaporisation",
+		},
+		{
+			input:            "1 GOUTTE DANS L'OEIL AFFECTE 4 FOIS PAR JOUR POUR 7 JOURS",
+			expectedDose:     "1",
+			expectedDoseUnit: "goutte",
+		},
+		{
+			input:            "APPLIQUER 2G. SUR LES ZONES DOULOUREUSES",
+			expectedDose:     "2",
+			expectedDoseUnit: "g",
+		},
+		{
+			input:            "PRENEZ LA CAPSULE EN MANGEANT - DOSE UNIQUE (INFECTION)",
+			expectedDose:     "1",
+			expectedDoseUnit: "capsule",
+		},
+		{
+			input:            "VAPORISEZ DANS LA BOUCHE AUX 5 MINUTES SI BESOIN",
+			expectedDose:     "1",
+			expectedDoseUnit: "vaporisation",
+		},
+		{
+			input:            "INHALER LE CONTENU D'UNE CAPSULE 1 FOIS PAR JOUR LE MATIN",
+			expectedDose:     "1",
+			expectedDoseUnit: "capsule",
+		},
+		{
+			input:            "PRENEZ 2 COMPRIMES MAINTENANT ET 1 COMPRIME APRÈS CHAQUE SELLE LIQUIDE-MAX 8/JR (DIARRHÉE)",
+			expectedDose:     "",
+			expectedDoseUnit: "",
+		},
+		{
+			input:            "TAKE 1 TABLET BY MOUTH 2 (TWO) TIMES A DAY",
+			expectedDose:     "1",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "TAKE ONE-HALF TABLET BY MOUTH DAILY",
+			expectedDose:     "0.5",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "TAKE 1 TO 2 TABLETS BY MOUTH EVERY 6 HOURS",
+			expectedDose:     "1-2",
+			expectedDoseUnit: "comprimé",
+		},
+		{
+			input:            "TAKE 2 CAPSULES BY MOUTH DAILY",
+			expectedDose:     "2",
+			expectedDoseUnit: "capsule",
+		},
+		{
+			input:            "INHALE 2 PUFFS INTO THE LUNGS EVERY 4 HOURS",
+			expectedDose:     "2",
+			expectedDoseUnit: "bouffée",
+		},
+		{
+			input:            "ADMINISTER 1 DROP INTO BOTH EYES TWICE DAILY",
+			expectedDose:     "1",
+			expectedDoseUnit: "goutte",
+		},
+		{
+			input:            "USE 2 SPRAYS IN EACH NOSTRIL DAILY",
+			expectedDose:     "2",
+			expectedDoseUnit: "vaporisation",
+		},
+		{
+			input:            "APPLY 1 PATCH ONTO THE SKIN ONCE A WEEK",
+			expectedDose:     "1",
+			expectedDoseUnit: "timbre",
+		},
+		{
+			input:            "PRENDRE AU BESOIN. MAX 4 COMPRIMES PAR JOUR",
+			expectedDose:     "",
+			expectedDoseUnit: "",
+		},
+		{
+			input:            "NE PAS DEPASSER 3 CAPSULES PAR JOUR",
+			expectedDose:     "",
+			expectedDoseUnit: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapDose", func(t *testing.T) {
+			actualDose, actualDoseUnit := MapDose(tc.input)
+
+			if actualDose != tc.expectedDose {
+				t.Errorf("Dose\nI: %v\nE: %v\nA: %v", tc.input, tc.expectedDose, actualDose)
+				return
+			}
+
+			if actualDoseUnit != tc.expectedDoseUnit {
+				t.Errorf("DoseUnit\nI: %v\nE: %v\nA: %v", tc.input, tc.expectedDoseUnit, actualDoseUnit)
+				return
+			}
+		})
+	}
+}
+
+func TestRemoveFraction(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "PRENDRE 1/2 COMPRIME",
+			expected: "PRENDRE 0.5 COMPRIME",
+		},
+		{
+			input:    "PRENDRE 1/4 COMPRIME",
+			expected: "PRENDRE 0.25 COMPRIME",
+		},
+		{
+			input:    "PRENDRE 3/4 COMPRIME",
+			expected: "PRENDRE 0.75 COMPRIME",
+		},
+		{
+			input:    "PRENDRE 1 1/2 COMPRIME",
+			expected: "PRENDRE 1.5 COMPRIME",
+		},
+		{
+			input:    "PRENDRE ½ COMPRIME",
+			expected: "PRENDRE 0.5 COMPRIME",
+		},
+		{
+			input:    "PRENDRE ¼ COMPRIME",
+			expected: "PRENDRE 0.25 COMPRIME",
+		},
+		{
+			input:    "PRENDRE ¾ COMPRIME",
+			expected: "PRENDRE 0.75 COMPRIME",
+		},
+		{
+			input:    "PRENDRE 1 1/4 COMPRIME",
+			expected: "PRENDRE 1.25 COMPRIME",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestRemoveFraction", func(t *testing.T) {
+			actual := RemoveFraction(tc.input)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestMapFrequency(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR",
+			expected: "2 fois par jour",
+		},
+		{
+			input:    "PRENDRE 1 À 2 COMPRIMES AUX 4 A 6  HEURES SI BESOIN (MAXIMUM 8 COMPRIMES PAR JOUR)",
+			expected: "q4-6h PRN",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME PAR JOUR",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME PAR JOUR MAXIMUM 10 COMPRIME PAR JOUR",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "PRENDRE AU BESOIN. MAX 4 COMPRIMES PAR JOUR",
+			expected: "",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME AU BESOIN. NE PAS DEPASSER 4 COMPRIMES PAR JOUR",
+			expected: "",
+		},
+		{
+			input:    "TAKE 1 TABLET AS NEEDED. DO NOT EXCEED 4 TABLETS PER DAY",
+			expected: "",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR AVANT LE DEJEUNER",
+			expected: "1 fois par jour avant le déjeuner",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME 30 MINUTES AVANT LE COUCHER - AU BESOIN (INSOMNIE)",
+			expected: "1 fois par jour au coucher PRN",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME PAR JOUR SANS ARRET",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME PAR JOUR AVEC LE DEJEUNER - RÉGULIÈREMENT (PRESSION)",
+			expected: "1 fois par jour au déjeuner",
+		},
+		{
+			input:    "PRENDRE 2 COMPRIMES LE 1ER JOUR, PUIS 1 COMPRIME 1 FOIS PAR JOUR AUX 24 HEURES DU 2IÈME AU 5IÈME JOUR",
+			expected: "",
+		},
+		{
+			input:    "VAPORISEZ 2 FOIS DANS LES NARINES LE MATIN - REGULIEREMENT (ALLERGIE)",
+			expected: "1 fois par jour le matin",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR 1/2 HEURE AVANT COUCHER",
+			expected: "1 fois par jour au coucher",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME AUX 4 HEURES - AU BESOIN (DOULEUR)",
+			expected: "q4h PRN",
+		},
+		{
+			input:    "PRENEZ 2 COMPRIMES AUX 6 HEURES - REGULIEREMENT (DOULEUR - FIEVRE)",
+			expected: "q6h",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME AUX 4 A 6 HEURES - AU BESOIN (DOULEUR)",
+			expected: "q4-6h PRN",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME PAR SEMAINE AVEC 120 ML D'EAU, LE MATIN, AU MOINS 30 MINUTES AVANT NOURRITURE OU AUTRE MEDICAMENT",
+			expected: "1 fois par semaine",
+		},
+		{
+			input:    "PRENDRE 1 CAPSULE UNE FOIS PAR JOUR, A LA MEME HEURE CHAQUE JOUR",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "TAKE 1 TABLET ONCE DAILY",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "TAKE 1 TABLET ONCE DAILY AT BEDTIME",
+			expected: "1 fois par jour au coucher",
+		},
+		{
+			input:    "TAKE 1 TABLET ONCE DAILY IN THE MORNING",
+			expected: "1 fois par jour le matin",
+		},
+		{
+			input:    "TAKE 1 TABLET DAILY WITH BREAKFAST - REGULARLY (PRESSURE)",
+			expected: "1 fois par jour au déjeuner",
+		},
+		{
+			input:    "TAKE 1 TABLET ONCE A WEEK",
+			expected: "1 fois par semaine",
+		},
+		{
+			input:    "1 VAPORISATION SOUS LA LANGUE AUX 5 MINUTES SI DOULEUR A LA POITRINE. MAX. 3 VAPORISATIONS SI BESOIN.",
+			expected: "q5min PRN",
+		},
+		{
+			input:    "1 COMPRIME TOUTES LES 4 HEURES SI DOULEURS",
+			expected: "q4h PRN",
+		},
+		{
+			input:    "COLLER UN TIMBRE, GARDER 24 HEURES, RETIRER ET CHANGER. POURSUIVRE PENDANT 6 SEMAINES ET PASSER A L'ETAPE 2",
+			expected: "1 fois par jour",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH 2 (TWO) TIMES A DAY",
+			expected: "2 fois par jour",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH TWICE DAILY",
+			expected: "2 fois par jour",
+		},
+		{
+			input:    "TAKE 1/2 TABLET BY MOUTH THREE TIMES A DAY WITH MEALS",
+			expected: "3 fois par jour",
+		},
+		{
+			input:    "TAKE 1 CAPSULE BY MOUTH 4 TIMES A DAY",
+			expected: "4 fois par jour",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY 6 (SIX) HOURS AS NEEDED FOR PAIN",
+			expected: "q6h PRN",
+		},
+		{
+			input:    "INHALE 2 PUFFS INTO THE LUNGS EVERY 4 TO 6 HOURS AS NEEDED",
+			expected: "q4-6h PRN",
+		},
+		{
+			input:    "TAKE 2 TABLETS BY MOUTH Q 6-8 HR",
+			expected: "q6-8h",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH DAILY WITH BREAKFAST",
+			expected: "1 fois par jour au déjeuner",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY MORNING AND 1 TABLET EVERY EVENING WITH FOOD",
+			expected: "2 fois par jour au déjeuner et au souper",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME ROSE LE MATIN ET 1 COMPRIME BLEU LE SOIR A AU MOINS 4 HEURES D'INTERVALLE",
+			expected: "2 fois par jour",
+		},
+		{
+			input:    "APPLIQUER 1 TIMBRE LE MATIN, GARDER EN PLACE 12 HEURES ET RETIRER LE SOIR AU COUCHER",
+			expected: "1 fois par jour le matin",
+		},
+		{
+			input:    "TAKE ONE CAPSULE BY MOUTH AT BEDTIME",
+			expected: "1 fois par jour au coucher",
+		},
+		{
+			input:    "APPLY 1 PATCH ONTO THE SKIN ONCE A WEEK",
+			expected: "1 fois par semaine",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY OTHER DAY",
+			expected: "aux 2 jours",
+		},
+		{
+			input:    "TAKE 2 TABLETS BY MOUTH ONCE",
+			expected: "1 fois",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapFrequency", func(t *testing.T) {
+			actual := MapFrequency(tc.input).Label()
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestMapRoute(t *testing.T) {
+	testCases := []struct {
+		input    string
+		doseUnit string
+		expected string
+	}{
+		{
+			input:    "INHALER LE CONTENU D'UNE CAPSULE 1 FOIS PAR JOUR LE MATIN",
+			doseUnit: "capsule",
+			expected: "inhalation",
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH DAILY",
+			doseUnit: "comprimé",
+			expected: "oral",
+		},
+		{
+			input:    "INHALE 2 PUFFS INTO THE LUNGS EVERY 4 HOURS",
+			doseUnit: "bouffée",
+			expected: "inhalation",
+		},
+		{
+			input:    "ADMINISTER 1 DROP INTO BOTH EYES TWICE DAILY",
+			doseUnit: "goutte",
+			expected: "dans les 2 yeux",
+		},
+		{
+			input:    "USE 2 SPRAYS IN EACH NOSTRIL DAILY",
+			doseUnit: "vaporisation",
+			expected: "nasale",
+		},
+		{
+			input:    "PLACE 1 TABLET UNDER THE TONGUE EVERY 5 MINUTES",
+			doseUnit: "comprimé",
+			expected: "sublingual",
+		},
+		{
+			input:    "INJECT 10 UNITS UNDER THE SKIN AT BEDTIME",
+			doseUnit: "unité",
+			expected: "sous-cutané",
+		},
+		{
+			input:    "APPLY TOPICALLY TO AFFECTED AREA TWICE DAILY",
+			doseUnit: "",
+			expected: "topique",
+		},
+		{
+			input:    "PRENEZ 2 INHALATIONS PAR LA BOUCHE 4 FOIS PAR JOUR - AU BESOIN (ASTHME)",
+			doseUnit: "bouffée",
+			expected: "inhalation",
+		},
+		{
+			input:    "TAKE 2 INHALATIONS ORALLY 4 TIMES A DAY - AS NEEDED (ASTHMA)",
+			doseUnit: "bouffée",
+			expected: "inhalation",
+		},
+		{
+			input:    "INJECT ONE DEVICE (0.15 MG TOTAL) INTO MUSCLE ONCE AS NEEDED FOR ANAPHYLAXIS",
+			doseUnit: "",
+			expected: "intramusculaire",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapRoute", func(t *testing.T) {
+			actual := MapRoute(tc.input, Dosage{DoseUnit: tc.doseUnit})
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestMapSteps(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []Step
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expected: nil,
+		},
+		{
+			input: "PRENDRE 2 COMPRIMES LE 1ER JOUR, PUIS 1 COMPRIME 1 FOIS PAR JOUR AUX 24 HEURES DU 2IEME AU 5IEME JOUR",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 4, Max: 4, Unit: "jour"}},
+			},
+		},
+		{
+			input: "PRENDRE 2 COMPRIMES IMMEDIATEMENT, PUIS 1 COMPRIME APRES CHAQUE SELLE LIQUIDE MAXIMUM 8 COMPRIMES PAR JOUR",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "après chaque selle"},
+			},
+		},
+		{
+			input: "PRENEZ 2 COMPRIMES MAINTENANT ET 1 COMPRIME APRES CHAQUE SELLE LIQUIDE-MAX 8/JR (DIARRHEE)",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "après chaque selle"},
+			},
+		},
+		{
+			input: "2 COMPRIMES IMMEDIATEMENT PUIS 1 COMPRIME 1 FOIS PAR JOUR DURANT 4 JOURS (INFECTION)",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 4, Max: 4, Unit: "jour"}},
+			},
+		},
+		{
+			input: "TAKE 2 TABLETS IMMEDIATELY THEN 1 TABLET DAILY FOR 4 DAYS (INFECTION)",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 4, Max: 4, Unit: "jour"}},
+			},
+		},
+		{
+			input: "TAKE 2 TABLETS ON THE FIRST DAY, AND THEN 1 TABLET ONCE DAILY EVERY 24 HOURS FROM THE 2ND TO THE 5TH DAY",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois"},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 4, Max: 4, Unit: "jour"}},
+			},
+		},
+		{
+			input: "PRENDRE 40 MG 1 FOIS PAR JOUR PENDANT 5 JOURS PUIS 30 MG 1 FOIS PAR JOUR PENDANT 5 JOURS PUIS 20 MG 1 FOIS PAR JOUR PENDANT 5 JOURS",
+			expected: []Step{
+				{Dose: "40", DoseMin: 40, DoseMax: 40, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+				{Dose: "30", DoseMin: 30, DoseMax: 30, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+				{Dose: "20", DoseMin: 20, DoseMax: 20, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+			},
+		},
+		{
+			input: "PRENDRE 40 MG PAR JOUR X 5 JOURS PUIS 30 MG PAR JOUR X 5 JOURS PUIS 20 MG PAR JOUR X 5 JOURS",
+			expected: []Step{
+				{Dose: "40", DoseMin: 40, DoseMax: 40, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+				{Dose: "30", DoseMin: 30, DoseMax: 30, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+				{Dose: "20", DoseMin: 20, DoseMax: 20, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 5, Max: 5, Unit: "jour"}},
+			},
+		},
+		{
+			// La fréquence ou l'ajustement après PUIS reprend la dose précédente
+			input: "INSEREZ 1 COMPRIME DANS LE VAGIN 1 FOIS PAR JOUR DURANT 14 JOURS PUIS 2 FOIS PAR SEMAINE",
+			expected: []Step{
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 14, Max: 14, Unit: "jour"}},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "2 fois par semaine"},
+			},
+		},
+		{
+			input: "INJECTER 0.6 MG SOUS-CUTANÉE 1 FOIS PAR JOUR POUR 7 JOURS PUIS AUGMENTER DE 0.6 MG PAR SEMAINE, JUSQU'À 3MG",
+			expected: []Step{
+				{Dose: "0.6", DoseMin: 0.6, DoseMax: 0.6, DoseUnit: "mg", Frequency: "1 fois par jour", Duration: Duration{Min: 7, Max: 7, Unit: "jour"}},
+				{Dose: "0.6", DoseMin: 0.6, DoseMax: 0.6, DoseUnit: "mg", Frequency: "1 fois par jour"},
+			},
+		},
+		{
+			input: "TAKE 1 CAPSULE TWICE DAILY FOR 7 DAYS THEN ONE CAPSULE DAILY",
+			expected: []Step{
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "capsule", Frequency: "2 fois par jour", Duration: Duration{Min: 7, Max: 7, Unit: "jour"}},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "capsule", Frequency: "1 fois par jour"},
+			},
+		},
+		{
+			input: "TAKE TWO TABLETS DAILY FOR 3 DAYS THEN TAKE ONE TABLET DAILY FOR 3 DAYS",
+			expected: []Step{
+				{Dose: "2", DoseMin: 2, DoseMax: 2, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 3, Max: 3, Unit: "jour"}},
+				{Dose: "1", DoseMin: 1, DoseMax: 1, DoseUnit: "comprimé", Frequency: "1 fois par jour", Duration: Duration{Min: 3, Max: 3, Unit: "jour"}},
+			},
+		},
+		{
+			// Une seule dose : pas d'étapes
+			input:    "AGITER PUIS VAPORISER 2 FOIS DANS CHAQUE NARINE 1 FOIS PAR JOUR",
+			expected: nil,
+		},
+		{
+			input:    "MELANGER 1 SACHET AVEC 240 ML D'EAU, PUIS NETTOYER CHAQUE NARINE AVEC 120 ML 2 FOIS PAR JOUR",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapSteps", func(t *testing.T) {
+			actual := MapSteps(tc.input)
+			// Le libellé Frequency suffit à valider la fréquence de chaque étape
+			for i := range actual {
+				actual[i].FrequencyDetail = Frequency{}
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestParseDoseRange(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expectedMin float64
+		expectedMax float64
+	}{
+		{
+			input:       "",
+			expectedMin: 0,
+			expectedMax: 0,
+		},
+		{
+			input:       "1",
+			expectedMin: 1,
+			expectedMax: 1,
+		},
+		{
+			input:       "1-2",
+			expectedMin: 1,
+			expectedMax: 2,
+		},
+		{
+			input:       "0.5-1",
+			expectedMin: 0.5,
+			expectedMax: 1,
+		},
+		{
+			input:       "1.25",
+			expectedMin: 1.25,
+			expectedMax: 1.25,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestParseDoseRange", func(t *testing.T) {
+			actualMin, actualMax := ParseDoseRange(tc.input)
+			if actualMin != tc.expectedMin || actualMax != tc.expectedMax {
+				t.Errorf("I: %v\nE: %v-%v\nA: %v-%v", tc.input, tc.expectedMin, tc.expectedMax, actualMin, actualMax)
+				return
+			}
+		})
+	}
+}
+
+func TestMapAllDoseRange(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expectedMin float64
+		expectedMax float64
+	}{
+		{
+			input:       "PRENDRE 1/2 A 1 COMPRIME AU COUCHER",
+			expectedMin: 0.5,
+			expectedMax: 1,
+		},
+		{
+			input:       "PRENDRE 1-2 CAPSULES 3 FOIS PAR JOUR",
+			expectedMin: 1,
+			expectedMax: 2,
+		},
+		{
+			input:       "2 GOUTTES DANS CHAQUE OEIL 2 FOIS PAR JOUR",
+			expectedMin: 2,
+			expectedMax: 2,
+		},
+		{
+			input:       "DISSOUDRE 17 GRAMMES DANS 250 ML DE LIQUIDE ET BOIRE 1 FOIS PAR JOUR",
+			expectedMin: 17,
+			expectedMax: 17,
+		},
+		{
+			input:       "COLLER UN TIMBRE, GARDER 24 HEURES, RETIRER ET CHANGER",
+			expectedMin: 1,
+			expectedMax: 1,
+		},
+		{
+			input:       "2 VAPORISATIONS DANS CHAQUE NARINE 1 FOIS PAR JOUR",
+			expectedMin: 2,
+			expectedMax: 2,
+		},
+		{
+			input:       "PRENDRE 2 INHALATIONS 4 FOIS PAR JOUR SI BESOIN",
+			expectedMin: 2,
+			expectedMax: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapAllDoseRange", func(t *testing.T) {
+			dosage, err := MapAll(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dosage.DoseMin != tc.expectedMin || dosage.DoseMax != tc.expectedMax {
+				t.Errorf("I: %v\nE: %v-%v\nA: %v-%v", tc.input, tc.expectedMin, tc.expectedMax, dosage.DoseMin, dosage.DoseMax)
+				return
+			}
+		})
+	}
+}
+
+func TestRemoveNumberWords(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "TAKE 1 TABLET BY MOUTH 2 (TWO) TIMES A DAY",
+			expected: "TAKE 1 TABLET BY MOUTH 2 TIMES A DAY",
+		},
+		{
+			input:    "TAKE ONE TABLET EVERY SIX HOURS",
+			expected: "TAKE 1 TABLET EVERY 6 HOURS",
+		},
+		{
+			input:    "TAKE ONE (1) CAPSULE",
+			expected: "TAKE 1 CAPSULE",
+		},
+		{
+			input:    "TAKE ONE-HALF TABLET",
+			expected: "TAKE 0.5 TABLET",
+		},
+		{
+			input:    "TAKE ONE AND ONE HALF TABLETS",
+			expected: "TAKE 1.5 TABLETS",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME",
+			expected: "PRENDRE 1 COMPRIME",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestRemoveNumberWords", func(t *testing.T) {
+			actual := RemoveNumberWords(tc.input)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+// Couverture minimale attendue sur le corpus anglais translate/testdata/in.txt
+func TestEnglishCorpus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("corpus complet")
+	}
+
+	file, err := os.Open("../translate/testdata/in.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	total, doses, routes, frequencies := 0, 0, 0, 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		dosage, err := MapAll(scanner.Text())
+		if err != nil {
+			t.Fatal(err)
+		}
+		total++
+		if dosage.Dose != "" {
+			doses++
+		}
+		if dosage.Route != "" {
+			routes++
+		}
+		if dosage.Frequency != "" {
+			frequencies++
+		}
+	}
+
+	for field, count := range map[string]int{"dose": doses, "route": routes, "frequency": frequencies} {
+		if float64(count) < 0.95*float64(total) {
+			t.Errorf("%s: %d/%d lignes", field, count, total)
+		}
+	}
+}
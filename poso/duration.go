@@ -0,0 +1,119 @@
+package poso
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Duration représente la durée de traitement. Pour « jusqu'à 10 jours », Min
+// vaut 0 et Max vaut 10.
+type Duration struct {
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Unit       string  `json:"unit"`
+	Indefinite bool    `json:"indefinite"`
+}
+
+var (
+	durationIndefiniteRule = newRule("durée.continu", `SANS ARRET|EN CONTINU\b|CONTINUELLEMENT|CONTINUOUSLY|INDEFINIMENT|INDEFINITELY`)
+	durationRule           = newRule("durée.pour", `(DURANT|PENDANT|POUR|FOR UP TO|FOR|UP TO|JUSQU'A(?: UN MAXIMUM DE)?|\bX) ?([0-9]+(?:[.,][0-9]+)?)(?: ?(?:A|-|TO) ?([0-9]+(?:[.,][0-9]+)?))? ?(JOURS?|DAYS?|SEMAINES?|WEEKS?|MOIS|MONTHS?|DOSES?)\b`)
+	durationDaysRangeRule  = newRule("durée.du-au-jour", `DU ([0-9]+)(?:IEME|ER|E) AU ([0-9]+)(?:IEME|E) JOUR|FROM THE ([0-9]+)(?:ST|ND|RD|TH) TO THE ([0-9]+)(?:ST|ND|RD|TH) DAY`)
+	durationFirstDayRule   = newRule("durée.1er-jour", `LE (1ER|PREMIER) JOUR|ON THE FIRST DAY`)
+	durationTotalDosesRule = newRule("durée.total-doses", `TOTAL ?: ?([0-9]+) DOSES?`)
+)
+
+func MapDuration(line string) Duration {
+	return mapDuration(line, nil)
+}
+
+func mapDuration(line string, t *trace) Duration {
+	duration := Duration{}
+
+	if isComplexDosage(line) {
+		return duration
+	}
+
+	if durationIndefiniteRule.MatchString(line) {
+		t.rule(durationIndefiniteRule, line)
+		duration.Indefinite = true
+	}
+
+	// POUR 7 JOURS, POUR 2 A 4 SEMAINES, FOR UP TO 10 DAYS, X 10 DAYS
+	if match := durationRule.FindStringSubmatch(line); match != nil {
+		t.rule(durationRule, line)
+
+		duration.Min = parseNumber(match[2])
+		duration.Max = duration.Min
+		if match[3] != "" {
+			duration.Max = parseNumber(match[3])
+		}
+		if strings.Contains(match[1], "UP TO") || strings.HasPrefix(match[1], "JUSQU") {
+			duration.Min = 0
+		}
+		duration.Unit = durationUnit(match[4])
+
+		return duration
+	}
+
+	// DU 2IEME AU 5IEME JOUR (inclusivement)
+	if match := durationDaysRangeRule.FindStringSubmatch(line); match != nil {
+		t.rule(durationDaysRangeRule, line)
+		first := parseNumber(match[1] + match[3])
+		last := parseNumber(match[2] + match[4])
+
+		duration.Min = last - first + 1
+		duration.Max = duration.Min
+		duration.Unit = "jour"
+
+		return duration
+	}
+
+	// LE 1ER JOUR n'est une durée que pour une fréquence répétée (ex: "2 FOIS
+	// PAR JOUR LE 1ER JOUR"); seul, c'est une prise unique (« 1 fois »)
+	if durationFirstDayRule.MatchString(line) && !mapFrequency(line, nil, nil).Once {
+		t.rule(durationFirstDayRule, line)
+		duration.Min, duration.Max, duration.Unit = 1, 1, "jour"
+		return duration
+	}
+
+	// (TOTAL: 2 DOSES)
+	if match := durationTotalDosesRule.FindStringSubmatch(line); match != nil {
+		t.rule(durationTotalDosesRule, line)
+		duration.Min = parseNumber(match[1])
+		duration.Max = duration.Min
+		duration.Unit = "dose"
+	}
+
+	return duration
+}
+
+func durationUnit(unit string) string {
+	switch {
+	case strings.HasPrefix(unit, "JOUR"), strings.HasPrefix(unit, "DAY"):
+		return "jour"
+	case strings.HasPrefix(unit, "SEMAINE"), strings.HasPrefix(unit, "WEEK"):
+		return "semaine"
+	case unit == "MOIS", strings.HasPrefix(unit, "MONTH"):
+		return "mois"
+	case strings.HasPrefix(unit, "DOSE"):
+		return "dose"
+	}
+	return ""
+}
+
+var (
+	thousandsRule = newRule("nombre.milliers", `^[0-9]{1,3}(,[0-9]{3})+$`)
+)
+
+func parseNumber(text string) float64 {
+	// 1,000 : séparateur de milliers, 1,5 : virgule décimale
+	if thousandsRule.MatchString(text) {
+		text = strings.Replace(text, ",", "", -1)
+	}
+
+	number, err := strconv.ParseFloat(strings.Replace(text, ",", ".", -1), 64)
+	if err != nil {
+		return 0
+	}
+	return number
+}
@@ -0,0 +1,80 @@
+package poso
+
+import (
+	"testing"
+)
+
+func TestMapDuration(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Duration
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expected: Duration{},
+		},
+		{
+			input:    "1 GOUTTE DANS L'OEIL AFFECTE 4 FOIS PAR JOUR POUR 7 JOURS",
+			expected: Duration{Min: 7, Max: 7, Unit: "jour"},
+		},
+		{
+			// Prise unique : pas de durée
+			input:    "PRENDRE 2 COMPRIMES LE 1ER JOUR",
+			expected: Duration{},
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR LE 1ER JOUR",
+			expected: Duration{Min: 1, Max: 1, Unit: "jour"},
+		},
+		{
+			input:    "APPLIQUER 2 FOIS PAR JOUR POUR 2 A 4 SEMAINES",
+			expected: Duration{Min: 2, Max: 4, Unit: "semaine"},
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY 6 (SIX) HOURS AS NEEDED (PAIN) FOR UP TO 10 DAYS",
+			expected: Duration{Min: 0, Max: 10, Unit: "jour"},
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY 6 HOURS AS NEEDED FOR MODERATE PAIN (4-6) FOR UP TO 10 DOSES",
+			expected: Duration{Min: 0, Max: 10, Unit: "dose"},
+		},
+		{
+			input:    "TAKE 1 TABLET REGULARLY FOR 28 DAYS - CONTINUOUSLY",
+			expected: Duration{Min: 28, Max: 28, Unit: "jour", Indefinite: true},
+		},
+		{
+			input:    "1 COMPRIME PAR JOUR SANS ARRET",
+			expected: Duration{Indefinite: true},
+		},
+		{
+			input:    "7.5 ML TWICE DAILY X 10 DAYS",
+			expected: Duration{Min: 10, Max: 10, Unit: "jour"},
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME PAR JOUR POUR 3 MOIS",
+			expected: Duration{Min: 3, Max: 3, Unit: "mois"},
+		},
+		{
+			input:    "1 INJECTION INTRAMUSCULAIRE DE 0.5 ML (50 MCG).REPETER 2 A 12 MOIS APRES LA 1ERE DOSE. (TOTAL: 2 DOSES)",
+			expected: Duration{Min: 2, Max: 2, Unit: "dose"},
+		},
+		{
+			input:    "1 COMPRIME 1 FOIS PAR JOUR AUX 24 HEURES DU 2IEME AU 5IEME JOUR",
+			expected: Duration{Min: 4, Max: 4, Unit: "jour"},
+		},
+		{
+			input:    "PRENDRE 2 COMPRIMES LE 1ER JOUR, PUIS 1 COMPRIME 1 FOIS PAR JOUR DU 2IEME AU 5IEME JOUR",
+			expected: Duration{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapDuration", func(t *testing.T) {
+			actual := MapDuration(tc.input)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package poso
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unités (singulier, pluriel)
+var englishUnits = map[string][2]string{
+	"comprimé":     {"tablet", "tablets"},
+	"capsule":      {"capsule", "capsules"},
+	"goutte":       {"drop", "drops"},
+	"vaporisation": {"spray", "sprays"},
+	"bouffée":      {"puff", "puffs"},
+	"timbre":       {"patch", "patches"},
+	"suppositoire": {"suppository", "suppositories"},
+	"sachet":       {"packet", "packets"},
+	"application":  {"application", "applications"},
+	"applicateur":  {"applicator", "applicators"},
+	"inhalateur":   {"inhaler", "inhalers"},
+	"pastille":     {"lozenge", "lozenges"},
+	"gomme":        {"piece of gum", "pieces of gum"},
+	"dose":         {"dose", "doses"},
+	"unité":        {"unit", "units"},
+	"mEq":          {"mEq", "mEq"},
+	"mcg":          {"mcg", "mcg"},
+	"mg":           {"mg", "mg"},
+	"ml":           {"mL", "mL"},
+	"g":            {"g", "g"},
+}
+
+// Voies (verbe, complément)
+var englishRoutes = map[string][2]string{
+	"oral":                {"Take", "by mouth"},
+	"sublingual":          {"Place", "under the tongue"},
+	"inhalation":          {"Inhale", ""},
+	"nasale":              {"Spray", "into the nose"},
+	"topique":             {"Apply", "to the affected area"},
+	"oculaire":            {"Instill", "into the affected eye(s)"},
+	"dans les 2 yeux":     {"Instill", "into both eyes"},
+	"oeil droit":          {"Instill", "into the right eye"},
+	"oeil gauche":         {"Instill", "into the left eye"},
+	"otique":              {"Instill", "into the affected ear(s)"},
+	"dans les 2 oreilles": {"Instill", "into both ears"},
+	"oreille droit":       {"Instill", "into the right ear"},
+	"oreille gauche":      {"Instill", "into the left ear"},
+	"rectal":              {"Insert", "rectally"},
+	"vaginal":             {"Insert", "vaginally"},
+	"sous-cutané":         {"Inject", "under the skin"},
+	"intramusculaire":     {"Inject", "into the muscle"},
+	"intraveineux":        {"Inject", "intravenously"},
+}
+
+var englishTimings = map[string]string{
+	"matin":          "in the morning",
+	"avant déjeuner": "before breakfast",
+	"déjeuner":       "with breakfast",
+	"dîner":          "with lunch",
+	"souper":         "with supper",
+	"soir":           "in the evening",
+	"coucher":        "at bedtime",
+}
+
+var englishPeriods = map[string]string{
+	"jour":    "day",
+	"semaine": "week",
+	"mois":    "month",
+	"dose":    "dose",
+}
+
+// Termes canoniques des indications et raisons PRN
+var englishTerms = map[string]string{
+	"acné":                    "acne",
+	"allergie":                "allergies",
+	"anaphylaxie":             "anaphylaxis",
+	"angine":                  "chest pain",
+	"anxiété":                 "anxiety",
+	"arthrite":                "arthritis",
+	"asthme":                  "asthma",
+	"calcium":                 "calcium",
+	"cessation tabagique":     "smoking cessation",
+	"céphalée":                "headache",
+	"cholestérol":             "cholesterol",
+	"circulation sanguine":    "blood circulation",
+	"congestion":              "congestion",
+	"constipation":            "constipation",
+	"contraception":           "contraception",
+	"crampes":                 "cramps",
+	"diabète":                 "diabetes",
+	"diarrhée":                "diarrhea",
+	"douleur":                 "pain",
+	"douleur légère":          "mild pain",
+	"douleur modérée":         "moderate pain",
+	"douleur sévère":          "severe pain",
+	"dysfonction érectile":    "erectile dysfunction",
+	"dyspnée":                 "shortness of breath",
+	"dépression":              "depression",
+	"eczéma":                  "eczema",
+	"enflure":                 "swelling",
+	"envie de fumer":          "cravings",
+	"fer":                     "iron",
+	"fièvre":                  "fever",
+	"glaucome":                "glaucoma",
+	"hormone":                 "hormones",
+	"humeur":                  "mood",
+	"hypertension":            "high blood pressure",
+	"hypoglycémie":            "low blood sugar",
+	"hémorroïdes":             "hemorrhoids",
+	"infection":               "infection",
+	"infection urinaire":      "urinary tract infection",
+	"inflammation":            "inflammation",
+	"insomnie":                "sleep",
+	"migraine":                "migraine",
+	"nausées":                 "nausea",
+	"nervosité":               "nervousness",
+	"ostéoporose":             "osteoporosis",
+	"potassium":               "potassium",
+	"prostate":                "prostate",
+	"prurit":                  "itching",
+	"psoriasis":               "psoriasis",
+	"reflux":                  "reflux",
+	"respiration sifflante":   "wheezing",
+	"rhinite":                 "rhinitis",
+	"réaction allergique":     "allergic reaction",
+	"réaction à la perfusion": "infusion reactions",
+	"spasmes musculaires":     "muscle spasms",
+	"TDAH":                    "ADHD",
+	"thyroïde":                "thyroid",
+	"toux":                    "cough",
+	"ulcères":                 "ulcers",
+	"vaccin":                  "vaccine",
+	"vertiges":                "dizziness",
+	"vessie":                  "bladder",
+	"vitamine":                "vitamins",
+	"vomissements":            "vomiting",
+	"yeux secs":               "dry eyes",
+	"épilepsie":               "seizures",
+	"éruption cutanée":        "rash",
+}
+
+// ToEnglish traduit une posologie analysée en anglais
+// (ex: "Take 1 tablet by mouth twice a day with breakfast and supper").
+// Retourne "" si la posologie n'a ni dose ni fréquence.
+func ToEnglish(dosage Dosage) string {
+	route := englishRoutes[dosage.Route]
+	verb := route[0]
+	if verb == "" {
+		verb = "Take"
+		if dosage.DoseUnit == "timbre" || dosage.DoseUnit == "application" {
+			verb = "Apply"
+		}
+	}
+
+	var parts []string
+	if len(dosage.Steps) > 0 {
+		var steps []string
+		for i, step := range dosage.Steps {
+			stepRoute := ""
+			if i == 0 {
+				stepRoute = route[1]
+			}
+			steps = append(steps, joinWords(
+				englishDose(step.Dose, step.DoseMax, step.DoseUnit),
+				stepRoute,
+				englishFrequency(step.FrequencyDetail),
+				englishDuration(step.Duration),
+			))
+		}
+		parts = append(parts, verb, strings.Join(steps, ", then "))
+	} else {
+		if dosage.Dose == "" && dosage.FrequencyDetail.IsZero() {
+			return ""
+		}
+		parts = append(parts,
+			verb,
+			englishDose(dosage.Dose, dosage.DoseMax, dosage.DoseUnit),
+			route[1],
+			englishFrequency(dosage.FrequencyDetail),
+		)
+	}
+
+	reason := englishTerm(dosage.PrnReason)
+	if dosage.Prn {
+		parts = append(parts, "as needed")
+		if reason != "" {
+			parts = append(parts, "for "+reason)
+		}
+	}
+	if indication := englishTerm(dosage.Indication.Term); indication != "" && indication != reason {
+		parts = append(parts, "for "+indication)
+	}
+
+	sig := joinWords(append(parts, englishDuration(dosage.Duration))...)
+
+	var maxDoses []string
+	for _, maxDose := range dosage.MaxDoses {
+		maxDoses = append(maxDoses, englishMaxDose(maxDose))
+	}
+	if maxDoses != nil {
+		sig += " (maximum " + strings.Join(maxDoses, ", ") + ")"
+	}
+
+	return sig
+}
+
+func englishDose(dose string, doseMax float64, unit string) string {
+	if dose == "" {
+		return ""
+	}
+
+	units, ok := englishUnits[unit]
+	if !ok {
+		return dose
+	}
+
+	dose = strings.Replace(dose, "-", " to ", 1)
+	if doseMax > 1 {
+		return dose + " " + units[1]
+	}
+	return dose + " " + units[0]
+}
+
+func englishFrequency(frequency Frequency) string {
+	label := ""
+
+	switch {
+	case frequency.Once:
+		label = "once"
+	case frequency.Times == 1:
+		label = "once a " + englishPeriods[frequency.Period]
+	case frequency.Times == 2:
+		label = "twice a " + englishPeriods[frequency.Period]
+	case frequency.Times > 2:
+		label = fmt.Sprintf("%d times a %s", frequency.Times, englishPeriods[frequency.Period])
+	case frequency.IntervalMin > 0:
+		label = englishInterval(frequency)
+	case frequency.Event == "selle":
+		label = "after each loose stool"
+	}
+
+	var timings []string
+	for _, timing := range frequency.Timings {
+		timings = append(timings, englishTimings[timing])
+	}
+	if len(timings) == 2 && frequency.Timings[0] == "déjeuner" && frequency.Timings[1] == "souper" {
+		timings = []string{"with breakfast and supper"}
+	}
+
+	return joinWords(label, strings.Join(timings, " and "))
+}
+
+func englishInterval(frequency Frequency) string {
+	units := map[string]string{"h": "hour", "min": "minute", "jour": "day", "semaine": "week", "mois": "month"}
+	unit := units[frequency.IntervalUnit]
+
+	if frequency.IntervalMax > frequency.IntervalMin {
+		return fmt.Sprintf("every %d to %d %ss", frequency.IntervalMin, frequency.IntervalMax, unit)
+	} else if frequency.IntervalMin == 1 {
+		return "every " + unit
+	} else if frequency.IntervalMin == 2 && unit == "day" {
+		return "every other day"
+	}
+	return fmt.Sprintf("every %d %ss", frequency.IntervalMin, unit)
+}
+
+func englishDuration(duration Duration) string {
+	if duration.Max == 0 {
+		if duration.Indefinite {
+			return "continuously"
+		}
+		return ""
+	}
+
+	unit := englishPeriods[duration.Unit]
+	if duration.Max > 1 {
+		unit += "s"
+	}
+
+	if duration.Min == 0 {
+		return fmt.Sprintf("for up to %g %s", duration.Max, unit)
+	} else if duration.Min < duration.Max {
+		return fmt.Sprintf("for %g to %g %s", duration.Min, duration.Max, unit)
+	}
+	return fmt.Sprintf("for %g %s", duration.Max, unit)
+}
+
+func englishMaxDose(maxDose MaxDose) string {
+	quantity := fmt.Sprintf("%g", maxDose.Quantity)
+	if units, ok := englishUnits[maxDose.Unit]; ok {
+		if maxDose.Quantity > 1 {
+			quantity += " " + units[1]
+		} else {
+			quantity += " " + units[0]
+		}
+	} else if maxDose.Unit != "" {
+		quantity += " " + maxDose.Unit
+	}
+
+	switch {
+	case maxDose.Period == "jour":
+		return quantity + " per day"
+	case strings.HasSuffix(maxDose.Period, "h"):
+		return quantity + " in " + strings.TrimSuffix(maxDose.Period, "h") + " hours"
+	}
+	return quantity
+}
+
+// englishTerm traduit un terme canonique (ou une liste "nausées, vomissements").
+// Retourne "" si un des termes est inconnu.
+func englishTerm(term string) string {
+	if term == "" {
+		return ""
+	}
+
+	var terms []string
+	for _, part := range strings.Split(term, ", ") {
+		english, ok := englishTerms[part]
+		if !ok {
+			return ""
+		}
+		terms = append(terms, english)
+	}
+	return strings.Join(terms, " or ")
+}
+
+func joinWords(words ...string) string {
+	var nonEmpty []string
+	for _, word := range words {
+		if word != "" {
+			nonEmpty = append(nonEmpty, word)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
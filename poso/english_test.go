@@ -0,0 +1,101 @@
+package poso
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestToEnglish(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR AU DEJEUNER ET AU SOUPER",
+			expected: "Take 1 tablet by mouth twice a day with breakfast and supper",
+		},
+		{
+			input:    "PRENDRE 1 A 2 COMPRIMES AUX 4 A 6 HEURES SI BESOIN (MAXIMUM 8 COMPRIMES PAR JOUR)",
+			expected: "Take 1 to 2 tablets by mouth every 4 to 6 hours as needed (maximum 8 tablets per day)",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME AUX 4 HEURES SI BESOIN CONTRE DOULEUR",
+			expected: "Take 1 tablet by mouth every 4 hours as needed for pain",
+		},
+		{
+			input:    "Prenez 1 comprimé par jour au coucher - régulièrement (Pression)",
+			expected: "Take 1 tablet by mouth once a day at bedtime for high blood pressure",
+		},
+		{
+			input:    "INSTILLER 1 GOUTTE DANS LES 2 YEUX 2 FOIS PAR JOUR",
+			expected: "Instill 1 drop into both eyes twice a day",
+		},
+		{
+			input:    "2 INHALATIONS 1 FOIS PAR JOUR RÉGULIEREMENT",
+			expected: "Inhale 2 puffs once a day",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR POUR 7 JOURS",
+			expected: "Take 1 tablet by mouth once a day for 7 days",
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME TOUS LES 2 JOURS",
+			expected: "Take 1 tablet by mouth every other day",
+		},
+		{
+			input:    "2 comprimés immédiatement puis 1 comprimé 1 fois par jour durant 4 jours (Infection)",
+			expected: "Take 2 tablets by mouth once, then 1 tablet once a day for 4 days for infection",
+		},
+		{
+			input:    "Installer en voie primaire dès l'arrivée du patient",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestToEnglish", func(t *testing.T) {
+			dosage, err := MapAll(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := ToEnglish(dosage)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+// Aucune traduction de in_sample.txt ne doit contenir de termes français
+func TestToEnglishSample(t *testing.T) {
+	file, err := os.Open("../in_sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	frenchWords := []string{"fois", "jour", "comprimé", "goutte", "heure", "besoin", "PRN"}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		dosage, err := MapAll(scanner.Text())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		english := ToEnglish(dosage)
+		if english == "" && dosage.Dose != "" && dosage.Frequency != "" {
+			t.Errorf("I: %v\nA: traduction vide", dosage.Text)
+		}
+		for _, word := range frenchWords {
+			if strings.Contains(english, word) {
+				t.Errorf("I: %v\nA: %v", dosage.Text, english)
+				break
+			}
+		}
+	}
+}
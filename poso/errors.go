@@ -0,0 +1,34 @@
+package poso
+
+import (
+	"fmt"
+)
+
+// Étapes de l'analyse où une erreur peut survenir
+const (
+	StageRead          = "lecture"
+	StageNormalization = "normalisation"
+	StageDose          = "dose"
+	StageRoute         = "voie"
+	StageMaxDose       = "dose maximale"
+	StageFrequency     = "fréquence"
+	StagePrn           = "au besoin"
+	StageDuration      = "durée"
+	StageIndication    = "indication"
+	StageValidation    = "validation"
+	StageCatalog       = "catalogue"
+)
+
+// ParseError est une erreur survenue à une étape de l'analyse d'une posologie
+type ParseError struct {
+	Line    int    `json:"line"` // 0 si inconnue
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("ligne %d : %s : %s", e.Line, e.Stage, e.Message)
+	}
+	return fmt.Sprintf("%s : %s", e.Stage, e.Message)
+}
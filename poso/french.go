@@ -0,0 +1,289 @@
+package poso
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unités (singulier, pluriel, féminin)
+var frenchUnits = map[string]struct {
+	singular, plural string
+	feminine         bool
+}{
+	"comprimé":     {"comprimé", "comprimés", false},
+	"capsule":      {"capsule", "capsules", true},
+	"goutte":       {"goutte", "gouttes", true},
+	"vaporisation": {"vaporisation", "vaporisations", true},
+	"bouffée":      {"bouffée", "bouffées", true},
+	"timbre":       {"timbre", "timbres", false},
+	"suppositoire": {"suppositoire", "suppositoires", false},
+	"sachet":       {"sachet", "sachets", false},
+	"application":  {"application", "applications", true},
+	"applicateur":  {"applicateur", "applicateurs", false},
+	"inhalateur":   {"inhalateur", "inhalateurs", false},
+	"pastille":     {"pastille", "pastilles", true},
+	"gomme":        {"gomme", "gommes", true},
+	"dose":         {"dose", "doses", true},
+	"unité":        {"unité", "unités", true},
+	"mEq":          {"mEq", "mEq", false},
+	"mcg":          {"mcg", "mcg", false},
+	"mg":           {"mg", "mg", false},
+	"ml":           {"ml", "ml", false},
+	"g":            {"g", "g", false},
+}
+
+// Voies (verbe, complément)
+var frenchRoutes = map[string][2]string{
+	"oral":                {"Prendre", "par la bouche"},
+	"sublingual":          {"Placer", "sous la langue"},
+	"inhalation":          {"Inhaler", ""},
+	"nasale":              {"Vaporiser", "dans le nez"},
+	"topique":             {"Appliquer", "sur la région atteinte"},
+	"oculaire":            {"Instiller", "dans l'oeil atteint"},
+	"dans les 2 yeux":     {"Instiller", "dans les 2 yeux"},
+	"oeil droit":          {"Instiller", "dans l'oeil droit"},
+	"oeil gauche":         {"Instiller", "dans l'oeil gauche"},
+	"otique":              {"Instiller", "dans l'oreille atteinte"},
+	"dans les 2 oreilles": {"Instiller", "dans les 2 oreilles"},
+	"oreille droit":       {"Instiller", "dans l'oreille droite"},
+	"oreille gauche":      {"Instiller", "dans l'oreille gauche"},
+	"rectal":              {"Insérer", "par voie rectale"},
+	"vaginal":             {"Insérer", "par voie vaginale"},
+	"sous-cutané":         {"Injecter", "sous la peau"},
+	"intramusculaire":     {"Injecter", "par voie intramusculaire"},
+	"intraveineux":        {"Injecter", "par voie intraveineuse"},
+}
+
+// Unités de temps (singulier, pluriel)
+var frenchPeriods = map[string][2]string{
+	"h":       {"heure", "heures"},
+	"min":     {"minute", "minutes"},
+	"jour":    {"jour", "jours"},
+	"semaine": {"semaine", "semaines"},
+	"mois":    {"mois", "mois"},
+	"dose":    {"dose", "doses"},
+}
+
+// ToFrench produit la posologie française normalisée d'une posologie analysée
+// (ex: "Prendre 1 comprimé par la bouche 2 fois par jour au déjeuner et au souper au besoin pour la douleur").
+// Retourne "" si la posologie n'a ni dose ni fréquence.
+func ToFrench(dosage Dosage) string {
+	route := frenchRoutes[dosage.Route]
+	verb := route[0]
+	if verb == "" {
+		verb = "Prendre"
+		if dosage.DoseUnit == "timbre" || dosage.DoseUnit == "application" {
+			verb = "Appliquer"
+		}
+	}
+
+	var parts []string
+	if len(dosage.Steps) > 0 {
+		var steps []string
+		for i, step := range dosage.Steps {
+			stepRoute := ""
+			if i == 0 {
+				stepRoute = route[1]
+			}
+			steps = append(steps, joinWords(
+				frenchDose(step.Dose, step.DoseMax, step.DoseUnit),
+				stepRoute,
+				frenchFrequency(step.FrequencyDetail),
+				frenchDuration(step.Duration),
+			))
+		}
+		parts = append(parts, verb, strings.Join(steps, ", puis "))
+	} else {
+		if dosage.Dose == "" && dosage.FrequencyDetail.IsZero() {
+			return ""
+		}
+		parts = append(parts,
+			verb,
+			frenchDose(dosage.Dose, dosage.DoseMax, dosage.DoseUnit),
+			route[1],
+			frenchFrequency(dosage.FrequencyDetail),
+			frenchDuration(dosage.Duration),
+		)
+	}
+
+	if dosage.Prn {
+		parts = append(parts, "au besoin")
+		if dosage.PrnReason != "" {
+			parts = append(parts, "pour "+frenchReason(dosage.PrnReason))
+		}
+	}
+	if dosage.Indication.Term != "" && dosage.Indication.Term != dosage.PrnReason {
+		parts = append(parts, "("+dosage.Indication.Term+")")
+	}
+
+	sig := joinWords(parts...)
+
+	var maxDoses []string
+	for _, maxDose := range dosage.MaxDoses {
+		maxDoses = append(maxDoses, frenchMaxDose(maxDose))
+	}
+	if maxDoses != nil {
+		sig += " (maximum " + strings.Join(maxDoses, ", ") + ")"
+	}
+
+	return sig
+}
+
+// Termes masculins et noms singuliers terminés par s ou x (voir frenchArticle)
+var frenchArticles = map[string]string{
+	"cholestérol": "le ",
+	"diabète":     "le ",
+	"prurit":      "le ",
+	"reflux":      "le ",
+	"TDAH":        "le ",
+	"glaucome":    "le ",
+	"vaccin":      "le ",
+	"fer":         "le ",
+	"calcium":     "le ",
+	"potassium":   "le ",
+	"psoriasis":   "le ",
+	"toux":        "la ",
+}
+
+// frenchReason précède chaque terme de la raison de son article (ex:
+// « la congestion et la toux »)
+func frenchReason(reason string) string {
+	var terms []string
+	for _, term := range strings.Split(reason, ", ") {
+		terms = append(terms, frenchArticle(term)+term)
+	}
+	return strings.Join(terms, " et ")
+}
+
+// frenchArticle retourne l'article défini d'une raison (ex: « la douleur »,
+// « l'anxiété », « les brûlements d'estomac »). Hors des termes connus, une
+// raison dont le premier mot se termine par s ou x est au pluriel et les
+// autres sont féminines.
+func frenchArticle(reason string) string {
+	if article, ok := frenchArticles[reason]; ok {
+		return article
+	}
+
+	noun, _, _ := strings.Cut(reason, " ")
+	plain, _ := RemoveAccents(noun)
+	first, _ := utf8.DecodeRuneInString(plain)
+	switch {
+	case strings.HasSuffix(noun, "s") || strings.HasSuffix(noun, "x"):
+		return "les "
+	case strings.ContainsRune("aeiouyh", unicode.ToLower(first)):
+		return "l'"
+	}
+	return "la "
+}
+
+// frenchDose accorde l'unité avec la dose (ex: « une goutte », « 1 comprimé »,
+// « 2 comprimés », « 0,5 à 1 comprimé »).
+func frenchDose(dose string, doseMax float64, unit string) string {
+	if dose == "" {
+		return ""
+	}
+
+	dose = strings.Replace(dose, ".", ",", -1)
+	dose = strings.Replace(dose, "-", " à ", 1)
+
+	units, ok := frenchUnits[unit]
+	if !ok {
+		return dose
+	}
+
+	if doseMax >= 2 {
+		return dose + " " + units.plural
+	}
+	if dose == "1" && units.feminine {
+		dose = "une"
+	}
+	return dose + " " + units.singular
+}
+
+func frenchFrequency(frequency Frequency) string {
+	label := ""
+
+	switch {
+	case frequency.Once:
+		label = "en une seule dose"
+	case frequency.Times > 0:
+		label = fmt.Sprintf("%d fois par %s", frequency.Times, frequency.Period)
+	case frequency.IntervalMin > 0:
+		label = frenchInterval(frequency)
+	case frequency.Event != "":
+		label = eventLabels[frequency.Event]
+	}
+
+	var timings []string
+	for _, timing := range frequency.Timings {
+		timings = append(timings, timingLabels[timing])
+	}
+
+	return joinWords(label, strings.Join(timings, " et "))
+}
+
+// frenchInterval retourne l'intervalle en toutes lettres (ex: « aux 4 à 6 heures »,
+// « toutes les heures »).
+func frenchInterval(frequency Frequency) string {
+	unit := frenchPeriods[frequency.IntervalUnit]
+	if frequency.IntervalMax > frequency.IntervalMin {
+		return fmt.Sprintf("aux %d à %d %s", frequency.IntervalMin, frequency.IntervalMax, unit[1])
+	} else if frequency.IntervalMin == 1 {
+		if frequency.IntervalUnit == "jour" || frequency.IntervalUnit == "mois" {
+			return "tous les " + unit[1]
+		}
+		return "toutes les " + unit[1]
+	}
+	return fmt.Sprintf("aux %d %s", frequency.IntervalMin, unit[1])
+}
+
+func frenchDuration(duration Duration) string {
+	if duration.Max == 0 {
+		if duration.Indefinite {
+			return "en continu"
+		}
+		return ""
+	}
+
+	unit := frenchPeriods[duration.Unit][0]
+	if duration.Max >= 2 {
+		unit = frenchPeriods[duration.Unit][1]
+	}
+
+	if duration.Unit == "dose" {
+		return fmt.Sprintf("pour %s %s", frenchNumber(duration.Max), unit)
+	} else if duration.Min == 0 {
+		return fmt.Sprintf("jusqu'à %s %s", frenchNumber(duration.Max), unit)
+	} else if duration.Min < duration.Max {
+		return fmt.Sprintf("pendant %s à %s %s", frenchNumber(duration.Min), frenchNumber(duration.Max), unit)
+	}
+	return fmt.Sprintf("pendant %s %s", frenchNumber(duration.Max), unit)
+}
+
+func frenchMaxDose(maxDose MaxDose) string {
+	quantity := frenchNumber(maxDose.Quantity)
+	if units, ok := frenchUnits[maxDose.Unit]; ok {
+		if maxDose.Quantity >= 2 {
+			quantity += " " + units.plural
+		} else {
+			quantity += " " + units.singular
+		}
+	} else if maxDose.Unit != "" {
+		quantity += " " + maxDose.Unit
+	}
+
+	switch {
+	case maxDose.Period == "jour":
+		return quantity + " par jour"
+	case strings.HasSuffix(maxDose.Period, "h"):
+		return quantity + " par " + strings.TrimSuffix(maxDose.Period, "h") + " heures"
+	}
+	return quantity
+}
+
+// frenchNumber formate un nombre avec une virgule décimale (ex: 0,5)
+func frenchNumber(number float64) string {
+	return strings.Replace(fmt.Sprintf("%g", number), ".", ",", 1)
+}
@@ -0,0 +1,133 @@
+package poso
+
+import (
+	"testing"
+)
+
+func TestToFrench(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR AU DEJEUNER ET AU SOUPER SI BESOIN",
+			expected: "Prendre 1 comprimé par la bouche 2 fois par jour au déjeuner et au souper au besoin",
+		},
+		{
+			input:    "PRENEZ 2 COMPRIMES 1 FOIS PAR JOUR LE MATIN",
+			expected: "Prendre 2 comprimés par la bouche 1 fois par jour le matin",
+		},
+		{
+			input:    "METTRE 1 GOUTTE DANS LES 2 YEUX 1 FOIS PAR JOUR AU COUCHER",
+			expected: "Instiller une goutte dans les 2 yeux 1 fois par jour au coucher",
+		},
+		{
+			input:    "PRENDRE 1/2 A 1 COMPRIME 1 FOIS PAR JOUR AU COUCHER",
+			expected: "Prendre 0,5 à 1 comprimé par la bouche 1 fois par jour au coucher",
+		},
+		{
+			input:    "PRENDRE 1 A 2 COMPRIMES AUX 4 A 6 HEURES SI BESOIN (MAXIMUM 8 COMPRIMES PAR JOUR)",
+			expected: "Prendre 1 à 2 comprimés par la bouche aux 4 à 6 heures au besoin (maximum 8 comprimés par jour)",
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME AUX 4 HEURES SI BESOIN CONTRE DOULEUR",
+			expected: "Prendre 1 comprimé par la bouche aux 4 heures au besoin pour la douleur",
+		},
+		{
+			input:    "Prenez 1 comprimé 3 fois par jour - au besoin (Nervosité - anxiété)",
+			expected: "Prendre 1 comprimé par la bouche 3 fois par jour au besoin pour la nervosité et l'anxiété",
+		},
+		{
+			// Raison libre : le texte original, avec ses accents
+			input:    "Prenez 1 comprimé aux 4 heures si brûlements d'estomac - au besoin",
+			expected: "Prendre 1 comprimé par la bouche aux 4 heures au besoin pour les brûlements d'estomac",
+		},
+		{
+			input:    "Prenez 1 capsule 2 fois par jour aux 12 heures - durant 7 jours (Infection)",
+			expected: "Prendre une capsule par la bouche 2 fois par jour pendant 7 jours (infection)",
+		},
+		{
+			input:    "PRENDRE 2 INHALATION 4 FOIS PAR JOUR SI BESOIN",
+			expected: "Inhaler 2 bouffées 4 fois par jour au besoin",
+		},
+		{
+			input:    "2 comprimés immédiatement puis 1 comprimé 1 fois par jour durant 4 jours (Infection)",
+			expected: "Prendre 2 comprimés par la bouche en une seule dose, puis 1 comprimé 1 fois par jour pendant 4 jours (infection)",
+		},
+		{
+			input:    "PRENDRE 2 COMPRIMÉS LE 1ER JOUR",
+			expected: "Prendre 2 comprimés par la bouche en une seule dose",
+		},
+		{
+			input:    "SELON LES DIRECTIVES DU MEDECIN",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestToFrench", func(t *testing.T) {
+			dosage, err := MapAll(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := ToFrench(dosage)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestFrenchDose(t *testing.T) {
+	testCases := []struct {
+		dose     string
+		doseMax  float64
+		unit     string
+		expected string
+	}{
+		{dose: "1", doseMax: 1, unit: "goutte", expected: "une goutte"},
+		{dose: "2", doseMax: 2, unit: "goutte", expected: "2 gouttes"},
+		{dose: "1", doseMax: 1, unit: "comprimé", expected: "1 comprimé"},
+		{dose: "1.5", doseMax: 1.5, unit: "comprimé", expected: "1,5 comprimé"},
+		{dose: "1-2", doseMax: 2, unit: "timbre", expected: "1 à 2 timbres"},
+		{dose: "5", doseMax: 5, unit: "ml", expected: "5 ml"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestFrenchDose", func(t *testing.T) {
+			actual := frenchDose(tc.dose, tc.doseMax, tc.unit)
+			if actual != tc.expected {
+				t.Errorf("I: %v %v\nE: %v\nA: %v", tc.dose, tc.unit, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestFrenchReason(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "douleur", expected: "la douleur"},
+		{input: "anxiété", expected: "l'anxiété"},
+		{input: "hypertension", expected: "l'hypertension"},
+		{input: "nausées, vomissements", expected: "les nausées et les vomissements"},
+		{input: "yeux secs", expected: "les yeux secs"},
+		{input: "toux", expected: "la toux"},
+		{input: "cholestérol", expected: "le cholestérol"},
+		{input: "brûlements d'estomac", expected: "les brûlements d'estomac"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestFrenchReason", func(t *testing.T) {
+			actual := frenchReason(tc.input)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package poso
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Frequency représente la fréquence d'administration. Une seule des formes
+// est utilisée : prise unique (Once), nombre de prises par période (Times et
+// Period), intervalle (IntervalMin à IntervalMax, en IntervalUnit) ou
+// événement (Event, ex: après chaque selle).
+type Frequency struct {
+	Once         bool     `json:"once,omitempty"`
+	Times        int      `json:"times,omitempty"`
+	Period       string   `json:"period,omitempty"`
+	IntervalMin  int      `json:"interval_min,omitempty"`
+	IntervalMax  int      `json:"interval_max,omitempty"`
+	IntervalUnit string   `json:"interval_unit,omitempty"`
+	Event        string   `json:"event,omitempty"`
+	Timings      []string `json:"timings,omitempty"`
+	Prn          bool     `json:"prn,omitempty"`
+}
+
+var timingLabels = map[string]string{
+	"matin":          "le matin",
+	"déjeuner":       "au déjeuner",
+	"avant déjeuner": "avant le déjeuner",
+	"dîner":          "au dîner",
+	"souper":         "au souper",
+	"soir":           "le soir",
+	"coucher":        "au coucher",
+}
+
+var eventLabels = map[string]string{
+	"selle": "après chaque selle",
+}
+
+// Label retourne le libellé de la fréquence (ex: "1 fois par jour au coucher PRN", "q4h", "q4-6h")
+func (f Frequency) Label() string {
+	label := ""
+
+	if f.Once {
+		label = "1 fois"
+	} else if f.Times > 0 {
+		label = fmt.Sprintf("%d fois par %s", f.Times, f.Period)
+	} else if f.IntervalMin > 0 && (f.IntervalUnit == "h" || f.IntervalUnit == "min") {
+		label = fmt.Sprintf("q%d%s", f.IntervalMin, f.IntervalUnit)
+		if f.IntervalMax > f.IntervalMin {
+			label = fmt.Sprintf("q%d-%d%s", f.IntervalMin, f.IntervalMax, f.IntervalUnit)
+		}
+	} else if f.IntervalMin > 0 {
+		label = fmt.Sprintf("aux %d %s", f.IntervalMin, f.IntervalUnit)
+		if f.IntervalMax > f.IntervalMin {
+			label = fmt.Sprintf("aux %d à %d %s", f.IntervalMin, f.IntervalMax, f.IntervalUnit)
+		}
+		if max(f.IntervalMin, f.IntervalMax) > 1 && f.IntervalUnit != "mois" {
+			label += "s"
+		}
+	} else if f.Event != "" {
+		label = eventLabels[f.Event]
+	} else {
+		return ""
+	}
+
+	var timings []string
+	for _, timing := range f.Timings {
+		timings = append(timings, timingLabels[timing])
+	}
+	if timings != nil {
+		label += " " + strings.Join(timings, " et ")
+	}
+
+	if f.Prn {
+		label += " PRN"
+	}
+
+	return label
+}
+
+func (f Frequency) IsZero() bool {
+	return f.Equal(Frequency{})
+}
+
+func (f Frequency) Equal(other Frequency) bool {
+	return f.Once == other.Once &&
+		f.Times == other.Times &&
+		f.Period == other.Period &&
+		f.IntervalMin == other.IntervalMin &&
+		f.IntervalMax == other.IntervalMax &&
+		f.IntervalUnit == other.IntervalUnit &&
+		f.Event == other.Event &&
+		slices.Equal(f.Timings, other.Timings) &&
+		f.Prn == other.Prn
+}
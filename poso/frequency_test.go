@@ -0,0 +1,117 @@
+package poso
+
+import (
+	"testing"
+)
+
+func TestMapFrequencyDetail(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Frequency
+	}{
+		{
+			input:    "SELON LES DIRECTIVES DU MEDECIN",
+			expected: Frequency{},
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR AU COUCHER SI BESOIN",
+			expected: Frequency{Times: 1, Period: "jour", Timings: []string{"coucher"}, Prn: true},
+		},
+		{
+			input:    "1 COMPRIME 2 FOIS PAR JOUR AU DEJEUNER ET AU SOUPER",
+			expected: Frequency{Times: 2, Period: "jour", Timings: []string{"déjeuner", "souper"}},
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME 5 FOIS PAR JOUR",
+			expected: Frequency{Times: 5, Period: "jour"},
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME AUX 4 A 6 HEURES - AU BESOIN (DOULEUR)",
+			expected: Frequency{IntervalMin: 4, IntervalMax: 6, IntervalUnit: "h", Prn: true},
+		},
+		{
+			input:    "APPLIQUER 2G. SUR LES ZONES DOULOUREUSES 2 FOIS PAR JOUR AUX 12 HEURES",
+			expected: Frequency{Times: 2, Period: "jour"},
+		},
+		{
+			input:    "PRENDRE 1 COMPRIME AUX 12 HEURES",
+			expected: Frequency{IntervalMin: 12, IntervalUnit: "h"},
+		},
+		{
+			input:    "TAKE 1 TABLET TWICE A WEEK",
+			expected: Frequency{Times: 2, Period: "semaine"},
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME IMMEDIATEMENT",
+			expected: Frequency{Once: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapFrequencyDetail", func(t *testing.T) {
+			actual := MapFrequency(tc.input)
+			if !actual.Equal(tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestFrequencyLabel(t *testing.T) {
+	testCases := []struct {
+		input    Frequency
+		expected string
+	}{
+		{
+			input:    Frequency{},
+			expected: "",
+		},
+		{
+			input:    Frequency{Times: 3, Period: "jour", Prn: true},
+			expected: "3 fois par jour PRN",
+		},
+		{
+			input:    Frequency{Times: 2, Period: "jour", Timings: []string{"déjeuner", "souper"}},
+			expected: "2 fois par jour au déjeuner et au souper",
+		},
+		{
+			input:    Frequency{Times: 1, Period: "jour", Timings: []string{"avant déjeuner"}},
+			expected: "1 fois par jour avant le déjeuner",
+		},
+		{
+			input:    Frequency{IntervalMin: 12, IntervalUnit: "h"},
+			expected: "q12h",
+		},
+		{
+			input:    Frequency{IntervalMin: 5, IntervalUnit: "min", Prn: true},
+			expected: "q5min PRN",
+		},
+		{
+			input:    Frequency{IntervalMin: 4, IntervalMax: 6, IntervalUnit: "h", Prn: true},
+			expected: "q4-6h PRN",
+		},
+		{
+			input:    Frequency{IntervalMin: 2, IntervalMax: 3, IntervalUnit: "jour"},
+			expected: "aux 2 à 3 jours",
+		},
+		{
+			input:    Frequency{Event: "selle"},
+			expected: "après chaque selle",
+		},
+		{
+			input:    Frequency{Once: true, Prn: true},
+			expected: "1 fois PRN",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestFrequencyLabel", func(t *testing.T) {
+			actual := tc.input.Label()
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
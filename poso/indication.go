@@ -0,0 +1,215 @@
+package poso
+
+import (
+	"slices"
+	"strings"
+)
+
+// Indication représente la raison du traitement. Term est le terme canonique
+// en français et Text, la portion correspondante du texte original.
+type Indication struct {
+	Term string `json:"term"`
+	Text string `json:"text"`
+}
+
+// Termes canoniques (en français) indexés par le texte normalisé
+var indications = map[string]string{
+	"DOULEUR":                          "douleur",
+	"DOULEURS":                         "douleur",
+	"PAIN":                             "douleur",
+	"MILD PAIN":                        "douleur légère",
+	"DOULEUR LEGERE":                   "douleur légère",
+	"MODERATE PAIN":                    "douleur modérée",
+	"DOULEUR MODEREE":                  "douleur modérée",
+	"SEVERE PAIN":                      "douleur sévère",
+	"DOULEUR SEVERE":                   "douleur sévère",
+	"DOULEUR ANGINEUSE":                "angine",
+	"DOULEUR A LA POITRINE":            "angine",
+	"CHEST PAIN":                       "angine",
+	"ANGINE":                           "angine",
+	"ANGINA":                           "angine",
+	"FIEVRE":                           "fièvre",
+	"FEVER":                            "fièvre",
+	"ENFLURE":                          "enflure",
+	"SWELLING":                         "enflure",
+	"INFLAMMATION":                     "inflammation",
+	"INFECTION":                        "infection",
+	"INFECTION URINAIRE":               "infection urinaire",
+	"URINARY TRACT INFECTION":          "infection urinaire",
+	"PRESSION":                         "hypertension",
+	"PRESSURE":                         "hypertension",
+	"BLOOD PRESSURE":                   "hypertension",
+	"HIGH BLOOD PRESSURE":              "hypertension",
+	"HYPERTENSION":                     "hypertension",
+	"CHOLESTEROL":                      "cholestérol",
+	"LIPIDES":                          "cholestérol",
+	"CIRCULATION SANGUINE":             "circulation sanguine",
+	"DIABETE":                          "diabète",
+	"DIABETES":                         "diabète",
+	"THYROIDE":                         "thyroïde",
+	"THYROID":                          "thyroïde",
+	"ASTHME":                           "asthme",
+	"ASTHMA":                           "asthme",
+	"WHEEZING":                         "respiration sifflante",
+	"RESPIRATION SIFFLANTE":            "respiration sifflante",
+	"SHORTNESS OF BREATH":              "dyspnée",
+	"SHORTNESS OF AIR":                 "dyspnée",
+	"DYSPNEE":                          "dyspnée",
+	"TOUX":                             "toux",
+	"COUGH":                            "toux",
+	"CONGESTION":                       "congestion",
+	"ALLERGIE":                         "allergie",
+	"ALLERGIES":                        "allergie",
+	"ALLERGY":                          "allergie",
+	"ALLERGIC SYMPTOMS":                "allergie",
+	"RHINITE":                          "rhinite",
+	"RHINITIS":                         "rhinite",
+	"PRURIT":                           "prurit",
+	"ITCHING":                          "prurit",
+	"DEMANGEAISONS":                    "prurit",
+	"PROSTATE":                         "prostate",
+	"VESSIE":                           "vessie",
+	"CONSTIPATION":                     "constipation",
+	"CRAMPES":                          "crampes",
+	"CRAMPING":                         "crampes",
+	"HYPOGLYCEMIE":                     "hypoglycémie",
+	"HYPOGLYCEMIA":                     "hypoglycémie",
+	"ENVIE DE FUMER":                   "envie de fumer",
+	"DIARRHEE":                         "diarrhée",
+	"DIARRHEA":                         "diarrhée",
+	"NAUSEES":                          "nausées",
+	"NAUSEE":                           "nausées",
+	"NAUSEA":                           "nausées",
+	"VOMISSEMENTS":                     "vomissements",
+	"VOMITING":                         "vomissements",
+	"ULCERES":                          "ulcères",
+	"ULCERS":                           "ulcères",
+	"REFLUX":                           "reflux",
+	"DEPRESSION":                       "dépression",
+	"HUMEUR":                           "humeur",
+	"MOOD":                             "humeur",
+	"ANXIETE":                          "anxiété",
+	"ANXIETY":                          "anxiété",
+	"NERVOSITE":                        "nervosité",
+	"INSOMNIE":                         "insomnie",
+	"INSOMNIA":                         "insomnie",
+	"SLEEP":                            "insomnie",
+	"TDAH":                             "TDAH",
+	"ADHD":                             "TDAH",
+	"EPILEPSIE":                        "épilepsie",
+	"EPILEPSY":                         "épilepsie",
+	"SEIZURES":                         "épilepsie",
+	"VERTIGES":                         "vertiges",
+	"DIZZINESS":                        "vertiges",
+	"MIGRAINE":                         "migraine",
+	"MAUX DE TETE":                     "céphalée",
+	"HEADACHE":                         "céphalée",
+	"HEADACHES":                        "céphalée",
+	"SPASMES MUSCULAIRES":              "spasmes musculaires",
+	"MUSCLE SPASMS":                    "spasmes musculaires",
+	"MUSCLE SPASM":                     "spasmes musculaires",
+	"OSTEOPOROSE":                      "ostéoporose",
+	"OSTEOPOROSIS":                     "ostéoporose",
+	"GLAUCOME":                         "glaucome",
+	"GLAUCOMA":                         "glaucome",
+	"YEUX SECS":                        "yeux secs",
+	"DRY EYES":                         "yeux secs",
+	"HEMORROIDES":                      "hémorroïdes",
+	"HEMORRHOIDS":                      "hémorroïdes",
+	"DYSFONCTION ERECTILE":             "dysfonction érectile",
+	"ERECTILE DYSFUNCTION":             "dysfonction érectile",
+	"CONTRACEPTION":                    "contraception",
+	"HORMONE":                          "hormone",
+	"NICOTINE":                         "cessation tabagique",
+	"VACCIN":                           "vaccin",
+	"VITAMINE":                         "vitamine",
+	"VITAMIN":                          "vitamine",
+	"FER":                              "fer",
+	"IRON":                             "fer",
+	"CALCIUM":                          "calcium",
+	"POTASSIUM":                        "potassium",
+	"ROUGEURS":                         "éruption cutanée",
+	"RASH":                             "éruption cutanée",
+	"ACNE":                             "acné",
+	"ECZEMA":                           "eczéma",
+	"PSORIASIS":                        "psoriasis",
+	"ARTHRITE":                         "arthrite",
+	"ARTHRITIS":                        "arthrite",
+	"REACTION ALLERGIQUE":              "réaction allergique",
+	"ALLERGIC REACTION":                "réaction allergique",
+	"ANAPHYLAXIE":                      "anaphylaxie",
+	"ANAPHYLAXIS":                      "anaphylaxie",
+	"NAUSEES/VOMISSEMENTS":             "nausées, vomissements",
+	"NAUSEA/VOMITING":                  "nausées, vomissements",
+	"REACTIONS RELIEES A LA PERFUSION": "réaction à la perfusion",
+}
+
+func MapIndication(line string) Indication {
+	return mapIndication(line, indications, nil)
+}
+
+var (
+	indicationParenthesesRule = newRule("indication.parenthèses", `\(([^()]*)\)`)
+	indicationForRule         = newRule("indication.pour", `\b(?:POUR|FOR) (?:LA |LE |LES |L'|THE )?([A-Z' /-]+)`)
+)
+
+func mapIndication(line string, lexicon map[string]string, t *trace) Indication {
+	// (PRESSION), (DOULEUR - FIEVRE), (PAIN OR FEVER)
+	matches := indicationParenthesesRule.FindAllStringSubmatch(line, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if term := lookupIndication(lexicon, matches[i][1]); term != "" {
+			t.ruleMatch(indicationParenthesesRule, matches[i][0])
+			return Indication{Term: term, Text: matches[i][1]}
+		}
+	}
+
+	// POUR LA TOUX, FOR NAUSEA OR VOMITING, AS NEEDED FOR MODERATE PAIN
+	for _, match := range indicationForRule.FindAllStringSubmatch(line, -1) {
+		if term, text := lookupIndicationPrefix(lexicon, match[1]); term != "" {
+			t.ruleMatch(indicationForRule, match[0])
+			return Indication{Term: term, Text: text}
+		}
+	}
+
+	return Indication{}
+}
+
+// lookupIndicationPrefix cherche la plus longue suite de mots au début du
+// texte qui correspond à une indication connue.
+func lookupIndicationPrefix(lexicon map[string]string, text string) (string, string) {
+	words := strings.Fields(text)
+	for k := len(words); k > 0; k-- {
+		prefix := strings.Join(words[:k], " ")
+		if term := lookupIndication(lexicon, prefix); term != "" {
+			return term, prefix
+		}
+	}
+	return "", ""
+}
+
+var (
+	indicationSeparatorRule = newRule("indication.séparateur", `\s+-\s+|\s+(?:OR|OU|ET|AND)\s+|/|-|,\s*`)
+)
+
+// lookupIndication retourne le terme canonique d'une indication, en séparant
+// les indications multiples (DOULEUR - FIEVRE). Toutes les parties doivent
+// être reconnues.
+func lookupIndication(lexicon map[string]string, text string) string {
+	text = strings.TrimSpace(text)
+	if term, ok := lexicon[text]; ok {
+		return term
+	}
+
+	var terms []string
+	for _, part := range indicationSeparatorRule.Split(text, -1) {
+		term, ok := lexicon[strings.TrimSpace(part)]
+		if !ok {
+			return ""
+		}
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+
+	return strings.Join(terms, ", ")
+}
@@ -0,0 +1,73 @@
+package poso
+
+import (
+	"testing"
+)
+
+func TestMapIndication(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Indication
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expected: Indication{},
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME PAR JOUR AVEC LE DEJEUNER - REGULIEREMENT (PRESSION)",
+			expected: Indication{Term: "hypertension", Text: "PRESSION"},
+		},
+		{
+			input:    "PRENEZ 1 COMPRIME 2 FOIS PAR JOUR AU DEJEUNER ET AU SOUPER - AU BESOIN (ENFLURE - DOULEUR)",
+			expected: Indication{Term: "enflure, douleur", Text: "ENFLURE - DOULEUR"},
+		},
+		{
+			input:    "TAKE 1 TABLET EVERY 6 HOURS AS NEEDED (PAIN OR FEVER)",
+			expected: Indication{Term: "douleur, fièvre", Text: "PAIN OR FEVER"},
+		},
+		{
+			input:    "VAPORISER 1 FOIS SOUS LA LANGUE AUX 5 MINUTES SI BESOIN, MAX : 3 DOSES (DOULEUR ANGINEUSE)",
+			expected: Indication{Term: "angine", Text: "DOULEUR ANGINEUSE"},
+		},
+		{
+			input:    "PRENDRE 1 A 2 COMPRIMES AUX 4 A 6 HEURES SI BESOIN (MAXIMUM 8 COMPRIMES PAR JOUR)",
+			expected: Indication{},
+		},
+		{
+			input:    "TAKE 1 TABLET BY MOUTH EVERY 8 HOURS AS NEEDED FOR NAUSEA OR VOMITING",
+			expected: Indication{Term: "nausées, vomissements", Text: "NAUSEA OR VOMITING"},
+		},
+		{
+			input:    "TAKE 50 MG BY MOUTH EVERY 6 (SIX) HOURS AS NEEDED FOR MODERATE PAIN (PAIN SCALE 4-7)",
+			expected: Indication{Term: "douleur modérée", Text: "MODERATE PAIN"},
+		},
+		{
+			input:    "TAKE 5 ML BY MOUTH NIGHTLY AS NEEDED FOR COUGH FOR UP TO 10 DAYS",
+			expected: Indication{Term: "toux", Text: "COUGH"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapIndication", func(t *testing.T) {
+			actual := MapIndication(tc.input)
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
+
+func TestMapAllIndicationText(t *testing.T) {
+	input := "Prenez 1 comprimé 1 fois par jour au coucher - régulièrement (Cholestérol)"
+
+	dosage, err := MapAll(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Indication{Term: "cholestérol", Text: "Cholestérol"}
+	if dosage.Indication != expected {
+		t.Errorf("I: %v\nE: %v\nA: %v", input, expected, dosage.Indication)
+	}
+}
@@ -0,0 +1,112 @@
+package poso
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxDose représente une dose maximale. Period vaut "jour", "24h" (ou "6h",
+// "12h", etc.) ou "épisode" lorsque la limite n'a pas de période.
+type MaxDose struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Period   string  `json:"period"`
+}
+
+var (
+	maxDoseRule = newRule("dose-max", `(MAX(?:IMUM)?\.?(?: DAILY (?:AMOUNT|DOSE)| DOSE)?(?: IS| OF| DE)?|NE PAS DEPASSER|(?:DO )?NOT (?:TO )?EXCEED) ?:? ?([0-9]+(?:[.,][0-9]+)*) ?(?:(COMPRIMES?|TABLETS?|TABS?|PILLS?|CAPLETS?|CO|CAPSULES?|GOUTTES?|DROPS?|VAPORISATIONS?|SPRAYS?|INHALATIONS?|PUFFS?|BOUFFEES?|DOSES?|APPLICATIONS?|APPLICATORS?|APPLICATEURS?|LOZENGES?|INHALERS?|INHALATEURS?|PACKETS?|SACHETS?|TIMBRES?|PATCH(?:ES)?|PASTILLES?|GOMMES?|MORCEAUX|UNITES?|UNITS?|MEQ|MCG|MG|MLS?|GRAMMES?|GRAMS?|G)\b\.?)? ?(?:(?:/|PAR |PER |A |IN |EN |DANS )(?:(?:ANY )?([0-9]+)[ -]?(?:HEURES|HOURS?|HRS|HR|H)\b|(JOUR|JR|DAY)))?`)
+)
+
+func MapMaxDoses(line string, dosage Dosage) []MaxDose {
+	return mapMaxDoses(line, dosage, nil)
+}
+
+func mapMaxDoses(line string, dosage Dosage, t *trace) []MaxDose {
+
+	var maxDoses []MaxDose
+	for _, match := range maxDoseRule.FindAllStringSubmatch(line, -1) {
+		if match[3] == "" && match[4] == "" && match[5] == "" {
+			// ex: JUSQU'A UN MAXIMUM DE 30 JOURS
+			continue
+		}
+		t.ruleMatch(maxDoseRule, match[0])
+
+		maxDose := MaxDose{
+			Quantity: parseNumber(match[2]),
+			Unit:     maxDoseUnit(match[3]),
+		}
+		if maxDose.Unit == "" {
+			maxDose.Unit = dosage.DoseUnit
+		}
+
+		if match[4] != "" {
+			hours, _ := strconv.Atoi(match[4])
+			maxDose.Period = fmt.Sprintf("%dh", hours)
+		} else if match[5] != "" || strings.Contains(match[1], "DAILY") {
+			maxDose.Period = "jour"
+		} else {
+			maxDose.Period = "épisode"
+		}
+
+		maxDoses = append(maxDoses, maxDose)
+	}
+
+	return maxDoses
+}
+
+// maskMaxDoses remplace les doses maximales par des espaces de même longueur,
+// pour que les règles de dose et de fréquence ne les lisent pas (ex: "MAX 4
+// COMPRIMES PAR JOUR" n'est ni une dose de 4 ni 1 fois par jour)
+func maskMaxDoses(line string) string {
+	return maxDoseRule.ReplaceAllStringFunc(line, func(s string) string {
+		if match := maxDoseRule.FindStringSubmatch(s); match[3] == "" && match[4] == "" && match[5] == "" {
+			return s
+		}
+		return strings.Repeat(" ", len(s))
+	})
+}
+
+func maxDoseUnit(unit string) string {
+	switch strings.TrimSuffix(unit, "S") {
+	case "COMPRIME", "TABLET", "TAB", "PILL", "CAPLET", "CO":
+		return "comprimé"
+	case "CAPSULE":
+		return "capsule"
+	case "GOUTTE", "DROP":
+		return "goutte"
+	case "VAPORISATION", "SPRAY":
+		return "vaporisation"
+	case "INHALATION", "PUFF", "BOUFFEE":
+		return "bouffée"
+	case "DOSE":
+		return "dose"
+	case "APPLICATION":
+		return "application"
+	case "APPLICATOR", "APPLICATEUR":
+		return "applicateur"
+	case "INHALER", "INHALATEUR":
+		return "inhalateur"
+	case "PACKET", "SACHET":
+		return "sachet"
+	case "TIMBRE", "PATCH", "PATCHE":
+		return "timbre"
+	case "PASTILLE", "LOZENGE":
+		return "pastille"
+	case "GOMME", "MORCEAUX":
+		return "gomme"
+	case "UNITE", "UNIT":
+		return "unité"
+	case "MEQ":
+		return "mEq"
+	case "MCG":
+		return "mcg"
+	case "MG":
+		return "mg"
+	case "ML":
+		return "ml"
+	case "G", "GRAM", "GRAMME":
+		return "g"
+	}
+	return ""
+}
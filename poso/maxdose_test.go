@@ -0,0 +1,73 @@
+package poso
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMapMaxDoses(t *testing.T) {
+	testCases := []struct {
+		input    string
+		doseUnit string
+		expected []MaxDose
+	}{
+		{
+			input:    "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expected: nil,
+		},
+		{
+			input:    "PRENDRE 1 A 2 COMPRIMES AUX 4 A 6 HEURES SI BESOIN (MAXIMUM 8 COMPRIMES PAR JOUR)",
+			doseUnit: "comprimé",
+			expected: []MaxDose{{Quantity: 8, Unit: "comprimé", Period: "jour"}},
+		},
+		{
+			input:    "APPLIQUER 2G. SUR LES ZONES DOULOUREUSES 2 FOIS PAR JOUR AUX 12 HEURES (MAX: 4G./JOUR)",
+			doseUnit: "g",
+			expected: []MaxDose{{Quantity: 4, Unit: "g", Period: "jour"}},
+		},
+		{
+			input:    "TAKE 1 TABLET (250 MG TOTAL) BY MOUTH 3 (THREE) TIMES A DAY MAX DAILY AMOUNT: 750 MG",
+			doseUnit: "comprimé",
+			expected: []MaxDose{{Quantity: 750, Unit: "mg", Period: "jour"}},
+		},
+		{
+			input:    "TAKE 2 TABLETS BY MOUTH EVERY 6 HOURS AS NEEDED MAX DAILY AMOUNT: 4,000 MG",
+			doseUnit: "comprimé",
+			expected: []MaxDose{{Quantity: 4000, Unit: "mg", Period: "jour"}},
+		},
+		{
+			input:    "TAKE 1 TABLET EVERY 4 HOURS AS NEEDED. DO NOT EXCEED 3000 MG IN 24 HOURS",
+			doseUnit: "comprimé",
+			expected: []MaxDose{{Quantity: 3000, Unit: "mg", Period: "24h"}},
+		},
+		{
+			input:    "1 VAPORISATION SOUS LA LANGUE AUX 5 MINUTES SI DOULEUR A LA POITRINE. MAX. 3 VAPORISATIONS SI BESOIN.",
+			doseUnit: "vaporisation",
+			expected: []MaxDose{{Quantity: 3, Unit: "vaporisation", Period: "épisode"}},
+		},
+		{
+			input:    "VAPORISER 1 FOIS SOUS LA LANGUE AUX 5 MINUTES SI BESOIN, MAX : 3 DOSES (DOULEUR ANGINEUSE)",
+			doseUnit: "vaporisation",
+			expected: []MaxDose{{Quantity: 3, Unit: "dose", Period: "épisode"}},
+		},
+		{
+			input:    "PRENEZ 2 COMPRIMES MAINTENANT ET 1 COMPRIME APRES CHAQUE SELLE LIQUIDE-MAX 8/JR (DIARRHEE)",
+			doseUnit: "comprimé",
+			expected: []MaxDose{{Quantity: 8, Unit: "comprimé", Period: "jour"}},
+		},
+		{
+			input:    "TAKE 5-10 MLS BY MOUTH EVERY 4 TO 6 HOURS AS NEEDED (COUGH) JUSQU'A UN MAXIMUM DE 30 JOURS",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapMaxDoses", func(t *testing.T) {
+			actual := MapMaxDoses(tc.input, Dosage{DoseUnit: tc.doseUnit})
+			if !slices.Equal(actual, tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package poso analyse les posologies (sigs) françaises et anglaises en
+// posologies structurées (dose, voie, fréquence, durée, etc.).
+package poso
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parser analyse des posologies. Le catalogue des fréquences, le lexique des
+// indications et les règles de chaque étape sont configurables. Ses champs
+// ne doivent plus être modifiés après la première utilisation; il peut alors
+// être partagé entre plusieurs goroutines.
+type Parser struct {
+	// Catalog associe les fréquences analysées à un FrequencyId
+	Catalog FrequencyCatalog
+
+	// Indications remplace le lexique des indications (texte normalisé en
+	// majuscules sans accents → terme canonique). Le lexique par défaut est
+	// utilisé s'il est nil.
+	Indications map[string]string
+
+	// Rules remplace les règles d'une ou plusieurs étapes de l'analyse
+	Rules Rules
+
+	// Explain ajoute à chaque posologie la règle qui a produit chacun de ses
+	// champs (Dosage.Trace)
+	Explain bool
+}
+
+// Rules remplace l'analyse d'une ou plusieurs étapes du Parser. Chaque
+// fonction reçoit la posologie normalisée (majuscules, sans accents). Une
+// fonction nil utilise les règles du package; une règle personnalisée peut
+// les appeler (MapDose, MapFrequency, etc.) pour les cas qu'elle ne traite
+// pas. Les étapes remplacées n'ont ni trace ni positions (Dosage.Spans).
+type Rules struct {
+	Dose       func(line string) (string, string)
+	Steps      func(line string) []Step
+	Route      func(line string, dosage Dosage) string
+	MaxDoses   func(line string, dosage Dosage) []MaxDose
+	Frequency  func(line string) Frequency
+	Prn        func(line string) (bool, string)
+	Duration   func(line string) Duration
+	Indication func(line string) Indication
+}
+
+// Identifiant des étapes remplacées dans la trace (Dosage.Trace)
+const customRule = "personnalisée"
+
+var defaultParser = NewParser()
+
+// NewParser retourne un Parser avec le catalogue des fréquences par défaut
+func NewParser() *Parser {
+	return &Parser{Catalog: DefaultFrequencyCatalog()}
+}
+
+// Parse analyse une posologie. Le texte original est conservé dans Dosage.Text.
+// En cas d'échec, l'erreur est un *ParseError indiquant l'étape en cause.
+func (p *Parser) Parse(line string) (dosage Dosage, err error) {
+	dosage.Text = line
+
+	// Une erreur inattendue dans une étape n'interrompt pas l'analyse des
+	// autres posologies
+	stage := StageNormalization
+	defer func() {
+		if r := recover(); r != nil {
+			dosage, err = Dosage{Text: dosage.Text}, &ParseError{Stage: stage, Message: fmt.Sprint(r)}
+		}
+	}()
+
+	line = strings.ToUpper(line)
+	line, err = RemoveAccents(line)
+	if err != nil {
+		return dosage, &ParseError{Stage: stage, Message: err.Error()}
+	}
+
+	t := newTrace(dosage.Text, line, p.Explain)
+
+	stage = StageDose
+	t.enter("dose")
+	if p.Rules.Dose != nil {
+		t.deduction(customRule)
+		dosage.Dose, dosage.DoseUnit = p.Rules.Dose(line)
+	} else {
+		dosage.Dose, dosage.DoseUnit = mapDose(line, t)
+	}
+	dosage.DoseMin, dosage.DoseMax = ParseDoseRange(dosage.Dose)
+	if p.Rules.Steps != nil {
+		dosage.Steps = p.Rules.Steps(line)
+	} else {
+		dosage.Steps = mapSteps(line, p.indications(), t)
+	}
+
+	// Pour une posologie à plusieurs étapes, l'unité de la première étape est utilisée
+	unitDosage := dosage
+	if unitDosage.DoseUnit == "" && len(dosage.Steps) > 0 {
+		unitDosage.DoseUnit = dosage.Steps[0].DoseUnit
+	}
+	stage = StageRoute
+	t.enter("route")
+	if p.Rules.Route != nil {
+		t.deduction(customRule)
+		dosage.Route = p.Rules.Route(line, unitDosage)
+	} else {
+		dosage.Route = mapRoute(line, unitDosage, t)
+	}
+	stage = StageMaxDose
+	t.enter("max_doses")
+	if p.Rules.MaxDoses != nil {
+		t.deduction(customRule)
+		dosage.MaxDoses = p.Rules.MaxDoses(line, unitDosage)
+	} else {
+		dosage.MaxDoses = mapMaxDoses(line, unitDosage, t)
+	}
+
+	stage = StageFrequency
+	t.enter("frequency")
+	if p.Rules.Frequency != nil {
+		t.deduction(customRule)
+		dosage.FrequencyDetail = p.Rules.Frequency(line)
+	} else {
+		dosage.FrequencyDetail = mapFrequency(line, p.indications(), t)
+	}
+	dosage.Frequency = dosage.FrequencyDetail.Label()
+	dosage.FrequencyId, _ = p.Catalog.Lookup(dosage.FrequencyDetail)
+	stage = StagePrn
+	t.enter("prn")
+	if p.Rules.Prn != nil {
+		t.deduction(customRule)
+		dosage.Prn, dosage.PrnReason = p.Rules.Prn(line)
+	} else {
+		dosage.Prn, dosage.PrnReason = mapPrn(line, p.indications(), t)
+	}
+	stage = StageDuration
+	t.enter("duration")
+	if p.Rules.Duration != nil {
+		t.deduction(customRule)
+		dosage.Duration = p.Rules.Duration(line)
+	} else {
+		dosage.Duration = mapDuration(line, t)
+	}
+
+	stage = StageIndication
+	t.enter("indication")
+	if p.Rules.Indication != nil {
+		t.deduction(customRule)
+		dosage.Indication = p.Rules.Indication(line)
+	} else {
+		dosage.Indication = mapIndication(line, p.indications(), t)
+	}
+	if start := strings.Index(line, dosage.Indication.Text); dosage.Indication.Text != "" && start >= 0 {
+		_, _, dosage.Indication.Text = t.original(start, start+len(dosage.Indication.Text))
+	}
+
+	dosage.Spans = t.elements("")
+	for i := range dosage.Steps {
+		dosage.Steps[i].Spans = t.elements(fmt.Sprintf("steps[%d].", i))
+	}
+	dosage.Trace = t.matches
+	return dosage, nil
+}
+
+func (p *Parser) indications() map[string]string {
+	if p.Indications == nil {
+		return indications
+	}
+	return p.Indications
+}
@@ -0,0 +1,172 @@
+package poso
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParserCatalog(t *testing.T) {
+	parser := NewParser()
+	parser.Catalog = FrequencyCatalog{
+		{Id: 501, Label: "BID", Frequency: Frequency{Times: 2, Period: "jour"}},
+	}
+
+	dosage, err := parser.Parse("PRENDRE 1 COMPRIME 2 FOIS PAR JOUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dosage.FrequencyId != 501 {
+		t.Errorf("E: 501\nA: %v", dosage.FrequencyId)
+	}
+
+	dosage, err = parser.Parse("PRENDRE 1 COMPRIME 3 FOIS PAR JOUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dosage.FrequencyId != 0 {
+		t.Errorf("E: 0\nA: %v", dosage.FrequencyId)
+	}
+}
+
+func TestParserIndications(t *testing.T) {
+	parser := NewParser()
+	parser.Indications = map[string]string{"GOUTTE": "goutte (arthrite)"}
+
+	dosage, err := parser.Parse("Prenez 1 comprimé par jour avec le déjeuner - régulièrement (Goutte)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dosage.Indication.Term != "goutte (arthrite)" || dosage.Indication.Text != "Goutte" {
+		t.Errorf("A: %+v", dosage.Indication)
+	}
+
+	dosage, err = parser.Parse("PRENDRE 1 COMPRIME AU COUCHER (CHOLESTEROL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dosage.Indication.Term != "" {
+		t.Errorf("A: %+v", dosage.Indication)
+	}
+
+	// Le PRN de la fréquence est celui de Dosage.Prn, avec le même lexique
+	for _, line := range []string{"PRENDRE 1 COMPRIME 2 FOIS PAR JOUR. CESSER SI DIARRHEE", "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR AU BESOIN SI GOUTTE"} {
+		dosage, err = parser.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dosage.Prn != dosage.FrequencyDetail.Prn {
+			t.Errorf("I: %v\nE: %v\nA: %v (%s)", line, dosage.Prn, dosage.FrequencyDetail.Prn, dosage.Frequency)
+		}
+	}
+}
+
+func TestParserRules(t *testing.T) {
+	parser := NewParser()
+	parser.Explain = true
+	parser.Rules.Frequency = func(line string) Frequency {
+		if strings.Contains(line, " QOD") {
+			return Frequency{IntervalMin: 2, IntervalUnit: "jour"}
+		}
+		return MapFrequency(line)
+	}
+	parser.Rules.Route = func(line string, dosage Dosage) string {
+		return "oral"
+	}
+
+	testCases := []struct {
+		input             string
+		expectedFrequency string
+		expectedRoute     string
+	}{
+		{input: "PRENDRE 1 COMPRIME QOD", expectedFrequency: "aux 2 jours", expectedRoute: "oral"},
+		{input: "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR", expectedFrequency: "2 fois par jour", expectedRoute: "oral"},
+		{input: "INSTILLER 1 GOUTTE DANS L'OEIL DROIT", expectedFrequency: "", expectedRoute: "oral"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestParserRules", func(t *testing.T) {
+			dosage, err := parser.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dosage.Frequency != tc.expectedFrequency || dosage.Route != tc.expectedRoute {
+				t.Errorf("I: %v\nE: %v, %v\nA: %v, %v", tc.input, tc.expectedFrequency, tc.expectedRoute, dosage.Frequency, dosage.Route)
+				return
+			}
+			// Les autres étapes gardent les règles du package
+			if dosage.Dose != "1" {
+				t.Errorf("I: %v\nE: 1\nA: %v", tc.input, dosage.Dose)
+			}
+		})
+	}
+
+	// Les étapes remplacées sont identifiées dans la trace
+	dosage, _ := parser.Parse("PRENDRE 1 COMPRIME QOD")
+	if !slices.Contains(dosage.Trace, RuleMatch{Field: "frequency", Rule: customRule, Start: -1, End: -1}) {
+		t.Errorf("A: %+v", dosage.Trace)
+	}
+}
+
+// Un même Parser utilisé par plusieurs goroutines donne les mêmes résultats
+// qu'une analyse séquentielle
+func TestParserConcurrent(t *testing.T) {
+	file, err := os.Open("../in_sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	parser := NewParser()
+	expected := make([]Dosage, len(lines))
+	for i, line := range lines {
+		expected[i], _ = parser.Parse(line)
+	}
+
+	actual := make([]Dosage, len(lines))
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(lines); i += 4 {
+				actual[i], _ = parser.Parse(lines[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := range lines {
+		if !reflect.DeepEqual(actual[i], expected[i]) {
+			t.Errorf("I: %v\nE: %+v\nA: %+v", lines[i], expected[i], actual[i])
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	testCases := []struct {
+		input    ParseError
+		expected string
+	}{
+		{input: ParseError{Line: 12, Stage: StageNormalization, Message: "texte invalide"}, expected: "ligne 12 : normalisation : texte invalide"},
+		{input: ParseError{Stage: StageDose, Message: "index out of range"}, expected: "dose : index out of range"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestParseError", func(t *testing.T) {
+			if actual := tc.input.Error(); actual != tc.expected {
+				t.Errorf("I: %+v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package poso
+
+import (
+	"strings"
+)
+
+// MapPrn indique si la posologie est au besoin (PRN) et retourne la raison,
+// en terme canonique lorsqu'elle est connue (ex: "douleur").
+func MapPrn(line string) (bool, string) {
+	return mapPrn(line, indications, nil)
+}
+
+var (
+	prnRule          = newRule("prn", `PRN|AU BESOIN|SI BESOIN|AS NEEDED|IF NEEDED|SI DOULEURS?`)
+	prnReasonRule    = newRule("prn.raison", `(?:PRN|AU BESOIN|SI BESOIN|AS NEEDED|IF NEEDED)(?: -)? (?:\(([^()]*)\)|(?:POUR|FOR|CONTRE|AGAINST) (?:LA |LE |LES |L'|THE )?([A-Z' /-]+)|([A-Z' /-]+))`)
+	prnIfRule        = newRule("prn.si", `\bSI ([A-Z' /-]+)`)
+	prnReasonEndRule = newRule("prn.fin-raison", ` - | ET | MAX`)
+)
+
+// mapPrn enregistre dans t la règle de PRN, puis celle de la raison sous le
+// champ prn_reason
+func mapPrn(line string, lexicon map[string]string, t *trace) (bool, string) {
+	isPrn := false
+	if prnRule.MatchString(line) {
+		t.rule(prnRule, line)
+		isPrn = true
+	}
+	t.enter("prn_reason")
+
+	// AU BESOIN (DOULEUR), AS NEEDED FOR NAUSEA, SI BESOIN CONTRE DOULEUR
+	for _, match := range prnReasonRule.FindAllStringSubmatch(line, -1) {
+		if term := lookupIndication(lexicon, match[1]); term != "" {
+			t.ruleMatch(prnReasonRule, match[0])
+			t.within("prn_reason", strings.TrimSpace(match[1]), match[0])
+			return true, term
+		}
+		if term, text := lookupIndicationPrefix(lexicon, match[2]+match[3]); term != "" {
+			t.ruleMatch(prnReasonRule, match[0])
+			t.within("prn_reason", text, match[0])
+			return true, term
+		}
+	}
+
+	// Sans AU BESOIN ni SI DOULEUR, SI … est une consigne (ex: "CESSER SI
+	// DIARRHEE") et non la raison d'un PRN
+	if !isPrn {
+		return false, ""
+	}
+
+	// SI DOULEURS, AU BESOIN SI ANXIETE OU NAUSEES, PRN SI REACTIONS RELIEES A LA PERFUSION
+	for _, match := range prnIfRule.FindAllStringSubmatch(line, -1) {
+		if strings.HasPrefix(match[1], "BESOIN") {
+			continue
+		}
+		if term, text := lookupIndicationPrefix(lexicon, match[1]); term != "" {
+			t.ruleMatch(prnIfRule, match[0])
+			t.within("prn_reason", text, match[0])
+			return true, term
+		}
+		t.ruleMatch(prnIfRule, match[0])
+		// Raison libre : le texte original, avec ses accents
+		reason := strings.TrimSpace(prnReasonEndRule.Split(match[1], 2)[0])
+		t.within("prn_reason", reason, match[0])
+		return true, strings.ToLower(t.originalOf(reason, match[0]))
+	}
+
+	return true, ""
+}
@@ -0,0 +1,95 @@
+package poso
+
+import (
+	"testing"
+)
+
+func TestMapPrn(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedPrn    bool
+		expectedReason string
+	}{
+		{
+			input:          "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+			expectedPrn:    false,
+			expectedReason: "",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR SI BESOIN",
+			expectedPrn:    true,
+			expectedReason: "",
+		},
+		{
+			input:          "1 COMPRIME TOUTES LES 4 HEURES SI DOULEURS",
+			expectedPrn:    true,
+			expectedReason: "douleur",
+		},
+		{
+			input:          "PRENEZ 1 COMPRIME AUX 4 HEURES - AU BESOIN (DOULEUR)",
+			expectedPrn:    true,
+			expectedReason: "douleur",
+		},
+		{
+			input:          "PRENEZ 1 COMPRIME 30 MINUTES AVANT LE COUCHER - AU BESOIN (INSOMNIE)",
+			expectedPrn:    true,
+			expectedReason: "insomnie",
+		},
+		{
+			input:          "TAKE 1 TABLET BY MOUTH EVERY 8 HOURS AS NEEDED FOR NAUSEA OR VOMITING",
+			expectedPrn:    true,
+			expectedReason: "nausées, vomissements",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME AUX 8 HEURES AU BESOIN SI ANXIETE OU NAUSEES ANTICIPATOIRES (CHIMIOTHERAPIE)",
+			expectedPrn:    true,
+			expectedReason: "anxiété, nausées",
+		},
+		{
+			input:          "PRENDRE 2 COMPRIMES 30 MINUTES AVANT LA PERFUSION PRN SI REACTIONS RELIEES A LA PERFUSION",
+			expectedPrn:    true,
+			expectedReason: "réaction à la perfusion",
+		},
+		{
+			// Sans AU BESOIN, SI … est une condition de la prise et non un PRN
+			input:          "PRENDRE 2 COMPRIMES 30 MINUTES AVANT LA PERFUSION SI REACTIONS RELIEES A LA PERFUSION",
+			expectedPrn:    false,
+			expectedReason: "",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR. CESSER SI DIARRHEE",
+			expectedPrn:    false,
+			expectedReason: "",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME 1 FOIS PAR JOUR. CONSULTER SI FIEVRE",
+			expectedPrn:    false,
+			expectedReason: "",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME SI BESOIN CONTRE DOULEUR",
+			expectedPrn:    true,
+			expectedReason: "douleur",
+		},
+		{
+			input:          "PRENDRE 1 COMPRIME 2 FOIS PAR JOUR AU BESOIN SI SURDOSE D'OPIOIDE : APPELER LE 911",
+			expectedPrn:    true,
+			expectedReason: "surdose d'opioide",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestMapPrn", func(t *testing.T) {
+			actualPrn, actualReason := MapPrn(tc.input)
+			if actualPrn != tc.expectedPrn {
+				t.Errorf("Prn\nI: %v\nE: %v\nA: %v", tc.input, tc.expectedPrn, actualPrn)
+				return
+			}
+
+			if actualReason != tc.expectedReason {
+				t.Errorf("PrnReason\nI: %v\nE: %v\nA: %v", tc.input, tc.expectedReason, actualReason)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package poso
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// rule est une expression régulière compilée une seule fois au chargement du
+// package. Elle n'est évaluée que si la ligne contient au moins un de ses
+// mots-clés, extraits automatiquement des littéraux obligatoires de
+// l'expression (ex: `([0-9]+) (GOUTTES?|DROPS?)` → GOUTTE, DROP).
+type rule struct {
+	id       string
+	re       *regexp.Regexp
+	keywords []string
+}
+
+// Toutes les règles du package, dans l'ordre de déclaration
+var rules []*rule
+
+func newRule(id string, pattern string) *rule {
+	r := &rule{id: id, re: regexp.MustCompile(pattern)}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err == nil {
+		r.keywords = requiredLiterals(parsed.Simplify())
+	}
+	rules = append(rules, r)
+	return r
+}
+
+// candidate indique si la ligne contient un des mots-clés de la règle
+func (r *rule) candidate(line string) bool {
+	if r.keywords == nil {
+		return true
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(line, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) MatchString(line string) bool {
+	return r.candidate(line) && r.re.MatchString(line)
+}
+
+func (r *rule) FindStringSubmatch(line string) []string {
+	if !r.candidate(line) {
+		return nil
+	}
+	return r.re.FindStringSubmatch(line)
+}
+
+func (r *rule) FindStringSubmatchIndex(line string) []int {
+	if !r.candidate(line) {
+		return nil
+	}
+	return r.re.FindStringSubmatchIndex(line)
+}
+
+func (r *rule) FindAllString(line string, n int) []string {
+	if !r.candidate(line) {
+		return nil
+	}
+	return r.re.FindAllString(line, n)
+}
+
+func (r *rule) FindAllStringSubmatch(line string, n int) [][]string {
+	if !r.candidate(line) {
+		return nil
+	}
+	return r.re.FindAllStringSubmatch(line, n)
+}
+
+func (r *rule) ReplaceAllString(line string, replacement string) string {
+	if !r.candidate(line) {
+		return line
+	}
+	return r.re.ReplaceAllString(line, replacement)
+}
+
+func (r *rule) ReplaceAllStringFunc(line string, replacement func(string) string) string {
+	if !r.candidate(line) {
+		return line
+	}
+	return r.re.ReplaceAllStringFunc(line, replacement)
+}
+
+func (r *rule) Split(line string, n int) []string {
+	if !r.candidate(line) {
+		return []string{line}
+	}
+	return r.re.Split(line, n)
+}
+
+// requiredLiterals retourne des littéraux dont au moins un apparaît dans
+// toute correspondance de l'expression, ou nil si aucun filtre n'est possible.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		return []string{string(re.Rune)}
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return nil
+		}
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpConcat:
+		// Le littéral le plus discriminant parmi les éléments obligatoires
+		var best []string
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals != nil && (best == nil || shortest(literals) > shortest(best)) {
+				best = literals
+			}
+		}
+		return best
+
+	case syntax.OpAlternate:
+		var literals []string
+		for _, sub := range re.Sub {
+			subLiterals := requiredLiterals(sub)
+			if subLiterals == nil {
+				return nil
+			}
+			literals = append(literals, subLiterals...)
+		}
+		return literals
+	}
+
+	return nil
+}
+
+func shortest(literals []string) int {
+	length := len(literals[0])
+	for _, literal := range literals[1:] {
+		length = min(length, len(literal))
+	}
+	return length
+}
@@ -0,0 +1,87 @@
+package poso
+
+import (
+	"os"
+	"reflect"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: `([0-9]+) (GOUTTES?|DROPS?)`, expected: []string{"GOUTTE", "DROP"}},
+		{input: `EVERY (OTHER|SECOND) DAY|UN JOUR SUR DEUX`, expected: []string{"EVERY ", "UN JOUR SUR DEUX"}},
+		{input: `\bQ ?DAY\b`, expected: []string{"DAY"}},
+		{input: `(?i)daily`, expected: nil},
+		{input: `[0-9]+`, expected: nil},
+		{input: `(NASAL)?[0-9]+`, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestRequiredLiterals", func(t *testing.T) {
+			parsed, err := syntax.Parse(tc.input, syntax.Perl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := requiredLiterals(parsed.Simplify())
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+// Le préfiltre ne doit jamais écarter une ligne que l'expression reconnaît
+func TestRuleCandidate(t *testing.T) {
+	testCases := []struct {
+		rule  *rule
+		input string
+	}{
+		{rule: doseDropRule, input: "INSTILLER 2 GOUTTES DANS CHAQUE OEIL"},
+		{rule: frequencyOtherDayRule, input: "TAKE 1 TABLET EVERY OTHER DAY"},
+		{rule: frequencyHoursRule, input: "PRENDRE 1 COMPRIME Q4H"},
+		{rule: routeOralRule, input: "PRENDRE 1 COMPRIME PO DIE"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestRuleCandidate", func(t *testing.T) {
+			if !tc.rule.re.MatchString(tc.input) || !tc.rule.MatchString(tc.input) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.rule.keywords, tc.rule.MatchString(tc.input))
+			}
+		})
+	}
+}
+
+// Sur les deux corpus, le préfiltre de chaque règle est sans faux négatif
+func TestRuleCandidateCorpus(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	var lines []string
+	for _, path := range []string{"../in_sample.txt", "../translate/testdata/in.txt"} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			normalized, err := RemoveAccents(strings.ToUpper(line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, normalized)
+		}
+	}
+
+	for _, r := range rules {
+		for _, line := range lines {
+			if r.re.MatchString(line) && !r.candidate(line) {
+				t.Errorf("I: %v\nE: %v\nA: %v", line, r.id, r.keywords)
+			}
+		}
+	}
+}
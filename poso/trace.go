@@ -0,0 +1,424 @@
+package poso
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// RuleMatch indique la règle qui a produit un champ d'une posologie et la
+// portion du texte qu'elle a reconnue (voir Parser.Explain)
+type RuleMatch struct {
+	Field   string `json:"field"`             // ex: "dose", "frequency", "steps[1].dose"
+	Rule    string `json:"rule"`              // ex: "dose.goutte"
+	Pattern string `json:"pattern,omitempty"` // expression régulière de la règle
+	Match   string `json:"match"`             // texte reconnu, en majuscules sans accents
+	Text    string `json:"text"`              // portion correspondante du texte original
+	Start   int    `json:"start"`             // position dans Dosage.Text (caractères), -1 si introuvable
+	End     int    `json:"end"`
+}
+
+// Span est la position d'un élément dans Dosage.Text, en caractères (End
+// exclu), et le texte correspondant
+type Span struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Spans donne la position dans Dosage.Text des passages qui ont produit
+// chaque élément. Un élément absent, déduit (ex: voie orale d'un comprimé)
+// ou introuvable dans le texte original n'a pas de position.
+type Spans struct {
+	Dose      *Span `json:"dose,omitempty"`
+	DoseUnit  *Span `json:"dose_unit,omitempty"`
+	Route     *Span `json:"route,omitempty"`
+	Frequency *Span `json:"frequency,omitempty"`
+	PrnReason *Span `json:"prn_reason,omitempty"`
+	Duration  *Span `json:"duration,omitempty"`
+}
+
+// Champs dont la correspondance entière de la règle forme le passage (ex:
+// "4 FOIS PAR JOUR"). Les autres passages sont les groupes nommés des règles
+// (ex: `(?P<dose>[0-9]+) (?P<dose_unit>GOUTTES?)`).
+var phraseFields = map[string]bool{"route": true, "frequency": true, "duration": true}
+
+// trace collecte les règles appliquées lors de l'analyse d'une posologie et
+// les passages du texte qui ont produit chaque élément. Une trace nil
+// n'enregistre rien.
+type trace struct {
+	text    string // texte original
+	line    string // texte en majuscules sans accents
+	field   string // champ en cours d'analyse
+	from    int    // début du segment en cours (étapes) dans line
+	explain bool
+	matches []RuleMatch
+	spans   map[string][2]int // positions dans line, par élément (ex: "steps[0].dose"), nil si aucune
+
+	// Position de chaque caractère du texte original dans line, calculée au
+	// premier besoin; nil si le texte est en ASCII (positions identiques)
+	offsets []offset
+	mapped  bool
+}
+
+// offset est la position d'un caractère du texte original dans le texte
+// normalisé (line) et dans le texte original (text), en octets
+type offset struct {
+	line, text int
+}
+
+func newTrace(text string, line string, explain bool) *trace {
+	return &trace{text: text, line: line, explain: explain}
+}
+
+// enter indique le champ produit par les règles enregistrées ensuite
+func (t *trace) enter(field string) {
+	if t != nil {
+		t.field = field
+	}
+}
+
+// segment indique le segment de la ligne analysé ensuite (étape); les
+// correspondances y sont cherchées en premier. "" revient à la ligne entière.
+func (t *trace) segment(text string) {
+	if t == nil {
+		return
+	}
+	if text == "" {
+		t.from = 0
+	} else if i := strings.Index(t.line[t.from:], text); i >= 0 {
+		t.from += i
+	}
+}
+
+// rule enregistre la première correspondance de r dans line
+func (t *trace) rule(r *rule, line string) {
+	if t == nil {
+		return
+	}
+	if loc := r.re.FindStringSubmatchIndex(line); loc != nil {
+		t.add(r, line, loc)
+	}
+}
+
+// ruleMatch enregistre une correspondance de r choisie parmi plusieurs
+func (t *trace) ruleMatch(r *rule, match string) {
+	if t == nil {
+		return
+	}
+	if loc := r.re.FindStringSubmatchIndex(match); loc != nil {
+		t.add(r, match, loc)
+	}
+}
+
+// within enregistre la position de l'élément name, dont le texte fait partie
+// de la correspondance match (ex: la raison d'un PRN)
+func (t *trace) within(name string, text string, match string) {
+	if t == nil || text == "" {
+		return
+	}
+	at, i := t.index(match), strings.Index(match, text)
+	if at >= 0 && i >= 0 {
+		t.span(name, at+i, at+i+len(text))
+	}
+}
+
+// originalOf retourne le texte original de text, cherché dans match, ou text
+// lui-même s'il est introuvable
+func (t *trace) originalOf(text string, match string) string {
+	if t == nil || text == "" {
+		return text
+	}
+	at, i := t.index(match), strings.Index(match, text)
+	if at < 0 || i < 0 {
+		return text
+	}
+	if _, _, original := t.original(at+i, at+i+len(text)); original != "" {
+		return original
+	}
+	return text
+}
+
+// deduction enregistre un champ déduit d'un autre plutôt que du texte
+// (ex: voie orale pour un comprimé)
+func (t *trace) deduction(id string) {
+	if t == nil || !t.explain {
+		return
+	}
+	t.matches = append(t.matches, RuleMatch{Field: t.field, Rule: id, Start: -1, End: -1})
+}
+
+// add enregistre la correspondance loc de r dans line. line est souvent le
+// texte normalisé lui-même; sinon (ligne transformée, segment d'une étape) la
+// correspondance y est cherchée.
+func (t *trace) add(r *rule, line string, loc []int) {
+	offset, exact := 0, line == t.line
+	if !exact {
+		if at := t.index(line[loc[0]:loc[1]]); at >= 0 {
+			offset, exact = at-loc[0], true
+		}
+	}
+	start, end := offset+loc[0], offset+loc[1]
+	if !exact {
+		start, end = t.locate(line[loc[0]:loc[1]])
+	}
+
+	if t.explain {
+		m := RuleMatch{Field: t.field, Rule: r.id, Pattern: r.re.String(), Match: line[loc[0]:loc[1]], Start: -1, End: -1}
+		if start >= 0 {
+			m.Start, m.End, m.Text = t.original(start, end)
+		}
+		t.matches = append(t.matches, m)
+	}
+	if start < 0 {
+		return
+	}
+
+	prefix := t.field[:strings.LastIndex(t.field, ".")+1]
+	if phraseFields[strings.TrimPrefix(t.field, prefix)] {
+		t.span(t.field, start, end)
+	}
+
+	// Dans une correspondance transformée, les groupes sont cherchés mot à mot
+	from := start
+	for i, name := range r.re.SubexpNames() {
+		groupStart, groupEnd := loc[2*i], loc[2*i+1]
+		if name == "" || groupStart < 0 {
+			continue
+		}
+		if exact {
+			t.span(prefix+name, offset+groupStart, offset+groupEnd)
+		} else if at, to := t.locateWords(strings.Fields(line[groupStart:groupEnd]), from); at >= 0 && to <= end {
+			t.span(prefix+name, at, to)
+			from = to
+		}
+	}
+}
+
+// span étend la position de l'élément name à line[start:end]
+func (t *trace) span(name string, start int, end int) {
+	// Sans les espaces de part et d'autre (ex: "G " de "2 G ")
+	for start < end && t.line[start] == ' ' {
+		start++
+	}
+	for end > start && t.line[end-1] == ' ' {
+		end--
+	}
+	if start == end {
+		return
+	}
+
+	if span, ok := t.spans[name]; ok {
+		start, end = min(start, span[0]), max(end, span[1])
+	} else if t.spans == nil {
+		t.spans = map[string][2]int{}
+	}
+	t.spans[name] = [2]int{start, end}
+}
+
+// index retourne la position de text dans le texte normalisé, à partir du
+// segment en cours s'il l'y trouve, ou -1
+func (t *trace) index(text string) int {
+	if i := strings.Index(t.line[t.from:], text); i >= 0 {
+		return t.from + i
+	}
+	return strings.Index(t.line, text)
+}
+
+// Écart maximal entre deux mots d'une correspondance transformée retrouvés
+// dans le texte normalisé (ex: " (1) " de "ONE (1) TABLET" devenu "1 TABLET")
+const maxWordGap = 8
+
+// Autres écritures des nombres remplacés par RemoveFraction et
+// RemoveNumberWords (ex: "0.5" → "1/2", "½", "HALF")
+var numberForms = func() map[string][]string {
+	forms := map[string][]string{
+		"0.5":  {"1/2", "½", "ONE-HALF", "ONE HALF", "HALF"},
+		"0.25": {"1/4", "¼"},
+		"0.75": {"3/4", "¾"},
+		"1.5":  {"1 1/2", "ONE AND ONE HALF", "ONE AND A HALF"},
+		"1.25": {"1 1/4"},
+	}
+	for word, number := range numberWords {
+		forms[number] = append(forms[number], word)
+	}
+	return forms
+}()
+
+// locate retourne la position dans le texte normalisé d'une correspondance
+// transformée, retrouvée mot à mot, ou -1, -1
+func (t *trace) locate(text string) (int, int) {
+	words := strings.Fields(text)
+	if start, end := t.locateWords(words, t.from); start >= 0 {
+		return start, end
+	}
+	return t.locateWords(words, 0)
+}
+
+// locateWords cherche à partir de from les mots, dans l'ordre, chacun suivant
+// le précédent d'au plus maxWordGap caractères
+func (t *trace) locateWords(words []string, from int) (int, int) {
+	if len(words) == 0 {
+		return -1, -1
+	}
+	for {
+		start, end := t.findWord(words[0], from, len(t.line))
+		if start < 0 {
+			return -1, -1
+		}
+		last := end
+		for _, word := range words[1:] {
+			if _, last = t.findWord(word, last, last+maxWordGap); last < 0 {
+				break
+			}
+		}
+		if last >= 0 {
+			return start, last
+		}
+		from = start + 1
+	}
+}
+
+// findWord retourne la première occurrence de word, ou d'une autre écriture
+// du même nombre, qui commence entre from et limit et n'est pas collée à un
+// autre mot
+func (t *trace) findWord(word string, from int, limit int) (int, int) {
+	start, end := -1, -1
+	for _, form := range append([]string{word}, numberForms[word]...) {
+		for at := from; at <= min(limit, len(t.line)); {
+			i := strings.Index(t.line[at:], form)
+			if i < 0 || at+i > limit {
+				break
+			}
+			if i, j := at+i, at+i+len(form); t.isolated(i, j) && (start < 0 || i < start) {
+				start, end = i, j
+				break
+			}
+			at += i + 1
+		}
+	}
+	return start, end
+}
+
+// isolated indique si line[start:end] n'est pas collé à une lettre ou un chiffre
+func (t *trace) isolated(start int, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(t.line[:start])
+	after, _ := utf8.DecodeRuneInString(t.line[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// original retourne la position en caractères et le texte original de
+// line[start:end], ou -1, -1 si start dépasse le texte
+func (t *trace) original(start int, end int) (int, int, string) {
+	offsets := t.runeOffsets()
+	if offsets == nil {
+		if start >= len(t.text) {
+			return -1, -1, ""
+		}
+		end = min(end, len(t.text))
+		return start, end, t.text[start:end]
+	}
+
+	// Premiers caractères dont la forme normalisée commence à start ou
+	// après, puis à end ou après
+	runes := len(offsets) - 1
+	from := sort.Search(runes, func(i int) bool { return offsets[i].line >= start })
+	if from == runes {
+		return -1, -1, ""
+	}
+	to := from + sort.Search(runes-from, func(i int) bool { return offsets[from+i].line >= end })
+	return from, to, t.text[offsets[from].text:offsets[to].text]
+}
+
+// runeOffsets retourne la position de chaque caractère du texte original dans
+// line, suivie de la fin des deux textes, ou nil si le texte est en ASCII. La
+// table est calculée une seule fois par posologie.
+func (t *trace) runeOffsets() []offset {
+	if t.mapped {
+		return t.offsets
+	}
+	t.mapped = true
+	if isASCII(t.text) {
+		return nil
+	}
+
+	at := 0
+	t.offsets = make([]offset, 0, len(t.text)+1)
+	for i, r := range t.text {
+		t.offsets = append(t.offsets, offset{line: at, text: i})
+
+		// Les caractères ASCII gardent leur longueur une fois normalisés
+		if r < utf8.RuneSelf {
+			at++
+		} else {
+			at += normalizedLen(r)
+		}
+	}
+	t.offsets = append(t.offsets, offset{line: at, text: len(t.text)})
+	return t.offsets
+}
+
+// normalizedLen retourne la longueur en octets de r en majuscules sans accents,
+// comme RemoveAccents(strings.ToUpper(string(r))) mais sans allocation pour les
+// lettres accentuées usuelles
+func normalizedLen(r rune) int {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], unicode.ToUpper(r))
+	decomposed := norm.NFD.Properties(buf[:n]).Decomposition()
+	if decomposed == nil {
+		decomposed = buf[:n]
+	}
+
+	// Longueur sans les diacritiques (ex: "É" → "E" + "\u0301" → 1)
+	length, bases := 0, 0
+	for len(decomposed) > 0 {
+		c, size := utf8.DecodeRune(decomposed)
+		if !unicode.Is(unicode.Mn, c) {
+			length, bases = length+size, bases+1
+		}
+		decomposed = decomposed[size:]
+	}
+
+	// Plusieurs caractères de base peuvent être recomposés (ex: voyelles
+	// indiennes) : la normalisation complète tranche
+	if bases > 1 {
+		normalized, err := RemoveAccents(strings.ToUpper(string(r)))
+		if err != nil {
+			return n
+		}
+		return len(normalized)
+	}
+	return length
+}
+
+// elements retourne les positions des éléments dont le nom commence par prefix
+// (ex: "steps[1].")
+func (t *trace) elements(prefix string) Spans {
+	return Spans{
+		Dose:      t.element(prefix + "dose"),
+		DoseUnit:  t.element(prefix + "dose_unit"),
+		Route:     t.element(prefix + "route"),
+		Frequency: t.element(prefix + "frequency"),
+		PrnReason: t.element(prefix + "prn_reason"),
+		Duration:  t.element(prefix + "duration"),
+	}
+}
+
+func (t *trace) element(name string) *Span {
+	span, ok := t.spans[name]
+	if !ok {
+		return nil
+	}
+	start, end, text := t.original(span[0], span[1])
+	if start < 0 {
+		return nil
+	}
+	return &Span{Start: start, End: end, Text: text}
+}
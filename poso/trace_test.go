@@ -0,0 +1,226 @@
+package poso
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParserExplain(t *testing.T) {
+	type match struct {
+		Field, Rule, Text string
+		Start, End        int
+	}
+
+	testCases := []struct {
+		input    string
+		expected []match
+	}{
+		{
+			"Instiller 2 gouttes dans l'œil gauche 4 fois par jour pendant 7 jours",
+			[]match{
+				{"dose", "dose.goutte", "2 gouttes", 10, 19},
+				{"route", "voie.oculaire", "œil", 27, 30},
+				{"route", "voie.gauche", "gauche", 31, 37},
+				{"frequency", "fréquence.fois-par-jour", "4 fois par jour", 38, 53},
+				{"duration", "durée.pour", "pendant 7 jours", 54, 69},
+			},
+		},
+		{
+			"Prendre 1 comprimé au coucher (Pression)",
+			[]match{
+				{"dose", "dose.comprimé", "1 comprimé", 8, 18},
+				{"route", "voie.unité-de-dose", "", -1, -1},
+				{"frequency", "fréquence.coucher", "au coucher", 19, 29},
+				{"indication", "indication.parenthèses", "(Pression)", 30, 40},
+			},
+		},
+		{
+			// "ONE (1) TABLET" devient "1 TABLET" : la correspondance est retrouvée mot à mot
+			"TAKE ONE (1) TABLET BY MOUTH EVERY 6 HOURS AS NEEDED FOR PAIN",
+			[]match{
+				{"dose", "dose.comprimé", "ONE (1) TABLET", 5, 19},
+				{"route", "voie.oral", "BY MOUTH", 20, 28},
+				{"frequency", "fréquence.heures", "EVERY 6 HOURS", 29, 42},
+				{"prn", "prn", "AS NEEDED", 43, 52},
+				{"prn_reason", "prn.raison", "AS NEEDED FOR PAIN", 43, 61},
+				{"indication", "indication.pour", "FOR PAIN", 53, 61},
+			},
+		},
+		{
+			"PRENDRE 2 COMPRIMES MAINTENANT PUIS 1 COMPRIME PAR JOUR POUR 4 JOURS",
+			[]match{
+				{"steps[0].dose", "dose.comprimé", "2 COMPRIMES", 8, 19},
+				{"steps[0].frequency", "fréquence.une-fois", "MAINTENANT", 20, 30},
+				{"steps[1].dose", "dose.comprimé", "1 COMPRIME", 36, 46},
+				{"steps[1].frequency", "fréquence.unités-par-jour", "1 COMPRIME PAR JOUR", 36, 55},
+				{"steps[1].duration", "durée.pour", "POUR 4 JOURS", 56, 68},
+				{"route", "voie.unité-de-dose", "", -1, -1},
+			},
+		},
+	}
+
+	parser := NewParser()
+	parser.Explain = true
+
+	for _, tc := range testCases {
+		t.Run("TestParserExplain", func(t *testing.T) {
+			dosage, err := parser.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []match
+			for _, m := range dosage.Trace {
+				actual = append(actual, match{m.Field, m.Rule, m.Text, m.Start, m.End})
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+// Sans Explain, la trace est vide et l'analyse est inchangée
+func TestParserExplainDisabled(t *testing.T) {
+	line := "Instiller 2 gouttes dans l'œil gauche 4 fois par jour pendant 7 jours"
+
+	dosage, err := NewParser().Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dosage.Trace != nil {
+		t.Errorf("E: nil\nA: %v", dosage.Trace)
+	}
+
+	parser := NewParser()
+	parser.Explain = true
+	explained, err := parser.Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explained.Trace = nil
+	if !reflect.DeepEqual(dosage, explained) {
+		t.Errorf("E: %+v\nA: %+v", dosage, explained)
+	}
+}
+
+func TestParserSpans(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Spans
+	}{
+		{
+			"Instiller 2 gouttes dans l'œil gauche 4 fois par jour pendant 7 jours",
+			Spans{
+				Dose:      &Span{10, 11, "2"},
+				DoseUnit:  &Span{12, 19, "gouttes"},
+				Route:     &Span{27, 37, "œil gauche"},
+				Frequency: &Span{38, 53, "4 fois par jour"},
+				Duration:  &Span{54, 69, "pendant 7 jours"},
+			},
+		},
+		{
+			// Le "1" de "DAY 1" n'est pas la dose de "ONE TABLET"
+			"DAY 1: TAKE ONE TABLET BY MOUTH DAILY AS NEEDED FOR PAIN",
+			Spans{
+				Dose:      &Span{12, 15, "ONE"},
+				DoseUnit:  &Span{16, 22, "TABLET"},
+				Route:     &Span{23, 31, "BY MOUTH"},
+				Frequency: &Span{32, 37, "DAILY"},
+				PrnReason: &Span{52, 56, "PAIN"},
+			},
+		},
+		{
+			"Prendre ½ comprimé au coucher au besoin si nausées",
+			Spans{
+				Dose:      &Span{8, 9, "½"},
+				DoseUnit:  &Span{10, 18, "comprimé"},
+				Frequency: &Span{19, 29, "au coucher"},
+				PrnReason: &Span{43, 50, "nausées"},
+			},
+		},
+		{
+			"VOIR FEUILLET",
+			Spans{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestParserSpans", func(t *testing.T) {
+			dosage, err := MapAll(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dosage.Spans, tc.expected) {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, spansString(tc.expected), spansString(dosage.Spans))
+			}
+		})
+	}
+}
+
+// Les étapes identiques ont chacune la position de leur propre texte
+func TestParserStepSpans(t *testing.T) {
+	dosage, err := MapAll("PRENDRE 1 COMPRIME 2 FOIS PAR JOUR PENDANT 3 JOURS PUIS 1 COMPRIME 1 FOIS PAR JOUR PENDANT 3 JOURS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Spans{
+		{Dose: &Span{8, 9, "1"}, DoseUnit: &Span{10, 18, "COMPRIME"}, Frequency: &Span{19, 34, "2 FOIS PAR JOUR"}, Duration: &Span{35, 50, "PENDANT 3 JOURS"}},
+		{Dose: &Span{56, 57, "1"}, DoseUnit: &Span{58, 66, "COMPRIME"}, Frequency: &Span{67, 82, "1 FOIS PAR JOUR"}, Duration: &Span{83, 98, "PENDANT 3 JOURS"}},
+	}
+	if len(dosage.Steps) != len(expected) {
+		t.Fatalf("E: %v\nA: %v", len(expected), len(dosage.Steps))
+	}
+	for i, step := range dosage.Steps {
+		if !reflect.DeepEqual(step.Spans, expected[i]) {
+			t.Errorf("Étape %d\nE: %v\nA: %v", i, spansString(expected[i]), spansString(step.Spans))
+		}
+	}
+}
+
+// Le texte de chaque position est celui de Dosage.Text entre Start et End
+func TestParserSpansCorpus(t *testing.T) {
+	file, err := os.Open("../in_sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		dosage, err := MapAll(scanner.Text())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		all := []Spans{dosage.Spans}
+		for _, step := range dosage.Steps {
+			all = append(all, step.Spans)
+		}
+		runes := []rune(dosage.Text)
+		for _, spans := range all {
+			for _, span := range []*Span{spans.Dose, spans.DoseUnit, spans.Route, spans.Frequency, spans.PrnReason, spans.Duration} {
+				if span != nil && (span.End > len(runes) || string(runes[span.Start:span.End]) != span.Text) {
+					t.Errorf("I: %v\nA: %+v", dosage.Text, *span)
+				}
+			}
+		}
+	}
+}
+
+func spansString(spans Spans) string {
+	var parts []string
+	for _, span := range []*Span{spans.Dose, spans.DoseUnit, spans.Route, spans.Frequency, spans.PrnReason, spans.Duration} {
+		if span == nil {
+			parts = append(parts, "-")
+		} else {
+			parts = append(parts, fmt.Sprintf("%+v", *span))
+		}
+	}
+	return strings.Join(parts, " ")
+}
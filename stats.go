@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+// Stats résume la couverture de l'analyse d'un lot de posologies
+type Stats struct {
+	Total       int          `json:"total"`
+	Fields      []FieldStats `json:"fields"`
+	DoseUnits   []ValueCount `json:"dose_units"`
+	Routes      []ValueCount `json:"routes"`
+	Frequencies []ValueCount `json:"frequencies"`
+	Unparsed    []ValueCount `json:"unparsed"` // posologies sans dose, fréquence ni étapes
+}
+
+// FieldStats compte les posologies dont le champ est rempli ou vide
+type FieldStats struct {
+	Field            string  `json:"field"`
+	Populated        int     `json:"populated"`
+	Empty            int     `json:"empty"`
+	PopulatedPercent float64 `json:"populated_percent"`
+	EmptyPercent     float64 `json:"empty_percent"`
+}
+
+// ValueCount compte les posologies ayant une même valeur ("" si vide)
+type ValueCount struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// Champs de Dosage dont la couverture est mesurée, avec leur test de remplissage
+var statsFields = []struct {
+	name      string
+	populated func(poso.Dosage) bool
+}{
+	{"dose", func(d poso.Dosage) bool { return d.Dose != "" }},
+	{"dose_unit", func(d poso.Dosage) bool { return d.DoseUnit != "" }},
+	{"route", func(d poso.Dosage) bool { return d.Route != "" }},
+	{"frequency", func(d poso.Dosage) bool { return d.Frequency != "" }},
+	{"frequency_id", func(d poso.Dosage) bool { return d.FrequencyId != 0 }},
+	{"duration", func(d poso.Dosage) bool { return d.Duration.Unit != "" || d.Duration.Indefinite }},
+	{"max_doses", func(d poso.Dosage) bool { return len(d.MaxDoses) > 0 }},
+	{"prn", func(d poso.Dosage) bool { return d.Prn }},
+	{"prn_reason", func(d poso.Dosage) bool { return d.PrnReason != "" }},
+	{"indication", func(d poso.Dosage) bool { return d.Indication.Term != "" }},
+	{"steps", func(d poso.Dosage) bool { return len(d.Steps) > 0 }},
+}
+
+// StatsWriter compile les statistiques des posologies et les écrit à la
+// fermeture, en texte ou en JSON
+type StatsWriter struct {
+	writer       io.Writer
+	format       string
+	top          int
+	total        int
+	populated    []int
+	doseUnits    map[string]int
+	routes       map[string]int
+	frequencies  map[string]int
+	unparsed     map[string]int
+	unparsedText map[string]string
+}
+
+func NewStatsWriter(w io.Writer, format string, top int) *StatsWriter {
+	return &StatsWriter{
+		writer:       w,
+		format:       format,
+		top:          top,
+		populated:    make([]int, len(statsFields)),
+		doseUnits:    map[string]int{},
+		routes:       map[string]int{},
+		frequencies:  map[string]int{},
+		unparsed:     map[string]int{},
+		unparsedText: map[string]string{},
+	}
+}
+
+func (s *StatsWriter) Write(dosage poso.Dosage) error {
+	s.total++
+	for i, field := range statsFields {
+		if field.populated(dosage) {
+			s.populated[i]++
+		}
+	}
+
+	s.doseUnits[dosage.DoseUnit]++
+	s.routes[dosage.Route]++
+	s.frequencies[dosage.Frequency]++
+
+	// Les posologies non analysées identiques à la casse et aux espaces près
+	// sont regroupées
+	if isUnparsed(dosage) {
+		key := strings.ToUpper(strings.Join(strings.Fields(dosage.Text), " "))
+		if s.unparsed[key] == 0 {
+			s.unparsedText[key] = strings.TrimSpace(dosage.Text)
+		}
+		s.unparsed[key]++
+	}
+	return nil
+}
+
+// Stats retourne les statistiques des posologies écrites jusqu'ici
+func (s *StatsWriter) Stats() Stats {
+	stats := Stats{
+		Total:       s.total,
+		DoseUnits:   s.counts(s.doseUnits, nil, 0),
+		Routes:      s.counts(s.routes, nil, 0),
+		Frequencies: s.counts(s.frequencies, nil, 0),
+		Unparsed:    s.counts(s.unparsed, s.unparsedText, s.top),
+	}
+
+	for i, field := range statsFields {
+		stats.Fields = append(stats.Fields, FieldStats{
+			Field:            field.name,
+			Populated:        s.populated[i],
+			Empty:            s.total - s.populated[i],
+			PopulatedPercent: percent(s.populated[i], s.total),
+			EmptyPercent:     percent(s.total-s.populated[i], s.total),
+		})
+	}
+	return stats
+}
+
+// counts trie les valeurs par nombre décroissant, puis par ordre alphabétique,
+// et garde les top premières (toutes si top vaut 0)
+func (s *StatsWriter) counts(values map[string]int, labels map[string]string, top int) []ValueCount {
+	counts := []ValueCount{}
+	for value, count := range values {
+		if label, ok := labels[value]; ok {
+			value = label
+		}
+		counts = append(counts, ValueCount{Value: value, Count: count, Percent: percent(count, s.total)})
+	}
+
+	slices.SortFunc(counts, func(a, b ValueCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+
+	if top > 0 && len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
+
+func (s *StatsWriter) Close() error {
+	stats := s.Stats()
+	if s.format == "json" {
+		jsonData, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = s.writer.Write(append(jsonData, '\n'))
+		return err
+	}
+	return PrintStats(s.writer, stats)
+}
+
+// PrintStats écrit les statistiques en texte, en colonnes alignées
+func PrintStats(w io.Writer, stats Stats) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "Posologies : %d\n\n", stats.Total)
+
+	fmt.Fprintf(writer, "Champ\tRemplis\t%%\tVides\t%%\n")
+	for _, field := range stats.Fields {
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%d\t%s\n", field.Field, field.Populated, formatPercent(field.PopulatedPercent), field.Empty, formatPercent(field.EmptyPercent))
+	}
+
+	distributions := []struct {
+		title  string
+		counts []ValueCount
+	}{
+		{"Unités de dose", stats.DoseUnits},
+		{"Voies", stats.Routes},
+		{"Fréquences", stats.Frequencies},
+		{"Posologies non analysées les plus fréquentes", stats.Unparsed},
+	}
+	for _, distribution := range distributions {
+		fmt.Fprintf(writer, "\n%s\n", distribution.title)
+		for _, count := range distribution.counts {
+			value := count.Value
+			if value == "" {
+				value = "(vide)"
+			}
+			fmt.Fprintf(writer, "  %d\t%s\t%s\n", count.Count, formatPercent(count.Percent), value)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func percent(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// formatPercent écrit un pourcentage avec une virgule décimale (ex: 95,1 %)
+func formatPercent(percent float64) string {
+	return strings.Replace(fmt.Sprintf("%.1f %%", percent), ".", ",", 1)
+}
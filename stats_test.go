@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestStatsWriter(t *testing.T) {
+	parser := poso.NewParser()
+	writer := NewStatsWriter(&bytes.Buffer{}, "text", 2)
+	for _, line := range []string{
+		"PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+		"PRENDRE 2 COMPRIMES 1 FOIS PAR JOUR",
+		"INSTILLER 1 GOUTTE DANS CHAQUE OEIL 2 FOIS PAR JOUR",
+		"SELON LES DIRECTIVES DU MEDECIN",
+		"selon les  directives du médecin",
+		"Selon les directives du medecin ",
+		"VOIR FEUILLET",
+		"TEL QUE PRESCRIT",
+	} {
+		dosage, err := parser.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Write(dosage); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := writer.Stats()
+	if stats.Total != 8 {
+		t.Errorf("E: %v\nA: %v", 8, stats.Total)
+	}
+
+	expectedDose := FieldStats{Field: "dose", Populated: 3, Empty: 5, PopulatedPercent: 37.5, EmptyPercent: 62.5}
+	if stats.Fields[0] != expectedDose {
+		t.Errorf("E: %+v\nA: %+v", expectedDose, stats.Fields[0])
+	}
+
+	expectedUnits := []ValueCount{{Value: "", Count: 5, Percent: 62.5}, {Value: "comprimé", Count: 2, Percent: 25}, {Value: "goutte", Count: 1, Percent: 12.5}}
+	if !reflect.DeepEqual(stats.DoseUnits, expectedUnits) {
+		t.Errorf("E: %+v\nA: %+v", expectedUnits, stats.DoseUnits)
+	}
+
+	// Regroupées à la casse et aux espaces près, limitées aux 2 plus fréquentes
+	expectedUnparsed := []ValueCount{{Value: "SELON LES DIRECTIVES DU MEDECIN", Count: 2, Percent: 25}, {Value: "TEL QUE PRESCRIT", Count: 1, Percent: 12.5}}
+	if !reflect.DeepEqual(stats.Unparsed, expectedUnparsed) {
+		t.Errorf("E: %+v\nA: %+v", expectedUnparsed, stats.Unparsed)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	stats := Stats{
+		Total:    4,
+		Fields:   []FieldStats{{Field: "dose", Populated: 3, Empty: 1, PopulatedPercent: 75, EmptyPercent: 25}},
+		Routes:   []ValueCount{{Value: "oral", Count: 3, Percent: 75}, {Value: "", Count: 1, Percent: 25}},
+		Unparsed: []ValueCount{{Value: "VOIR FEUILLET", Count: 1, Percent: 25}},
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := PrintStats(buffer, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"Posologies : 4", "dose   3        75,0 %  1      25,0 %", "  3  75,0 %  oral", "  1  25,0 %  (vide)", "  1  25,0 %  VOIR FEUILLET"} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("E: %q\nA: %s", expected, buffer)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package translate
+
+import (
+	"bufio"
+	"os"
+	"testing"
+)
+
+// Traduction complète du corpus anglais
+func BenchmarkTransform(b *testing.B) {
+	file, err := os.Open("testdata/in.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			Transform(line)
+		}
+	}
+}
@@ -1,63 +1,87 @@
-package old
+// Package translate traduit les posologies anglaises (EN) en français (FR).
+package translate
 
 import (
 	"bufio"
-	"fmt"
-	"log"
-	"os"
+	"io"
 	"regexp"
 	"strings"
 )
 
-func main() {
-	file, err := os.Open("in.txt")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer file.Close()
+// Step est une étape de traduction appliquée à une ligne complète.
+type Step func(line string) string
+
+// Steps contient les étapes appliquées par Transform, dans l'ordre.
+var Steps = []Step{
+	RemoveThousandsSeparators,
+	TranslateTerms,
+	FeminineAgreement,
+	DecimalComma,
+	removeDoubleParentheses,
+}
 
-	outFile, err := os.Create("out.txt")
-	if err != nil {
-		log.Fatal(err)
-		return
+// Transform traduit une posologie anglaise en français.
+func Transform(line string) string {
+	for _, step := range Steps {
+		line = step(line)
 	}
-	defer outFile.Close()
+	return line
+}
 
-	writer := bufio.NewWriter(outFile)
+// TransformAll traduit chaque ligne de r et écrit le résultat dans w.
+func TransformAll(r io.Reader, w io.Writer) error {
+	writer := bufio.NewWriter(w)
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		oLine := scanner.Text()
-
-		line := Transform(oLine)
-
-		// _, err = writer.WriteString(oLine + "\n" + line + "\n\n")
-		_, err = writer.WriteString(line + "\n")
-		if err != nil {
-			log.Fatal(err)
-			return
+		if _, err := writer.WriteString(Transform(scanner.Text()) + "\n"); err != nil {
+			return err
 		}
-
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
-	}
-	err = writer.Flush()
-	if err != nil {
-		log.Fatal(err)
-		return
+		return err
 	}
+	return writer.Flush()
 }
 
-func Transform(line string) string {
-	// On enlève les séparateurs de miliers
-	re := regexp.MustCompile(`(\d{1,3})(,\d{3})*`)
-	line = re.ReplaceAllStringFunc(line, func(s string) string {
+// Expressions régulières compilées une seule fois au chargement du package
+var (
+	thousandsRe         = regexp.MustCompile(`(\d{1,3})(,\d{3})*`)
+	sprayVerbRe         = regexp.MustCompile(`^SPRAY `)
+	decimalTotalRe      = regexp.MustCompile(`\(([\d.]+) (MG|G) TOTAL\)`)
+	thousandsTotalRe    = regexp.MustCompile(`\((\d{1,3}(?:,\d{3})*) MG TOTAL\)`)
+	maxDailyRe          = regexp.MustCompile(`MAX(IMUM)? DAILY AMOUNT(:| IS)? (\d+) (MG|MCG|MEQ|ML|COMPRIMÉS|COMPRIMÉ|GOUTTES|GOUTTE|PASTILLES|UNITÉS|MCG|INHALATIONS|INHALATION|CAPSULE|BOUFFÉES|BOUFFÉE|APPLICATEUR|VAPORISATIONS|VAPORISATION|TIMBRE|APPLICATION|G|INHALATEURS|INHALATEUR|DOSES|DOSE)`)
+	maxDailyDecimalRe   = regexp.MustCompile(`MAX(IMUM)? DAILY AMOUNT: (\d+)\.(\d+) (MG|MCG|ML|COMPRIMÉS|COMPRIMÉ)`)
+	maxDailyThousandsRe = regexp.MustCompile(`MAX DAILY AMOUNT: (\d+),(\d+) (MG|ML|COMPRIMÉS|COMPRIMÉ)`)
+	onceDailyRe         = regexp.MustCompile(`(ONCE|ONE TIME) (DAILY|A DAY)`)
+	twoTimesDailyRe     = regexp.MustCompile(`(2|2 \(TWO\)|TWO) TIMES (DAILY|A DAY)`)
+	twiceDailyRe        = regexp.MustCompile(`TWICE (DAILY|A DAY)`)
+	threeTimesDailyRe   = regexp.MustCompile(`(3|3 \(THREE\)|THREE) TIMES (DAILY|A DAY)`)
+	fourTimesDailyRe    = regexp.MustCompile(`(4|4 \(FOUR\)|FOUR) TIMES (DAILY|A DAY)`)
+	everyFewDaysRe      = regexp.MustCompile(`EVERY ([2-9]) DAYS`)
+	everyTwelveHoursRe  = regexp.MustCompile(`EVERY (12|12 \(TWELVE\)|TWELVE) HOURS`)
+	everyDaysRe         = regexp.MustCompile(`EVERY (\d+) DAYS`)
+	inHoursRe           = regexp.MustCompile(`IN (\d+) HOURS`)
+	forDaysRe           = regexp.MustCompile(`FOR (\d+) DAYS`)
+	timesDaysRe         = regexp.MustCompile(`X (\d+) DAYS`)
+	oneStartRe          = regexp.MustCompile(`^ONE\s`)
+	oneRe               = regexp.MustCompile(`\sONE\s`)
+	twoRe               = regexp.MustCompile(`\sTWO\s`)
+	twoStartRe          = regexp.MustCompile(`^TWO\s`)
+	decimalPointRe      = regexp.MustCompile(`([0-9])\.([0-9])`)
+	everyHoursRes       = compileEveryHours()
+)
+
+// RemoveThousandsSeparators enlève les séparateurs de milliers (ex: 1,000 → 1000)
+func RemoveThousandsSeparators(line string) string {
+	return thousandsRe.ReplaceAllStringFunc(line, func(s string) string {
 		return strings.ReplaceAll(s, ",", "")
 	})
+}
 
+// TranslateTerms remplace les termes et expressions anglais par leur équivalent français.
+func TranslateTerms(line string) string {
 	line = strings.Replace(line, "TAKE IN AM", "PRENDRE LE MATIN", -1)
 	line = strings.Replace(line, "TAKE", "PRENDRE", -1)
 	line = strings.Replace(line, "ADMINISTER", "ADMINISTRER", -1)
@@ -74,8 +98,7 @@ func Transform(line string) string {
 
 	line = strings.Replace(line, "PLEASE OBTAIN MEDICINE (OVER THE COUNTER) FROM YOUR LOCAL PHARMACY", "VEUILLER VOUS PROCURER CE MÉDICAMENT (EN VENTE LIBRE) DANS VOTRE PHARMACIE COMMUNAUTAIRE", -1)
 
-	re = regexp.MustCompile(`^SPRAY `)
-	line = re.ReplaceAllString(line, "VAPORISER ")
+	line = sprayVerbRe.ReplaceAllString(line, "VAPORISER ")
 
 	line = strings.Replace(line, "ONE-HALF", "1/2", -1)
 
@@ -92,11 +115,9 @@ func Transform(line string) string {
 	line = strings.Replace(line, "APPLICATOR", "APPLICATEUR", -1)
 	line = strings.Replace(line, "PILL", "PILULE", -1)
 
-	re = regexp.MustCompile(`\(([\d.]+) (MG|G) TOTAL\)`)
-	line = re.ReplaceAllStringFunc(line, replaceDecimalDoseWithComma)
+	line = decimalTotalRe.ReplaceAllStringFunc(line, replaceDecimalDoseWithComma)
 
-	re = regexp.MustCompile(`\((\d{1,3}(?:,\d{3})*) MG TOTAL\)`)
-	line = re.ReplaceAllStringFunc(line, removeCommaInThousand)
+	line = thousandsTotalRe.ReplaceAllStringFunc(line, removeCommaInThousand)
 
 	line = strings.Replace(line, "BY MOUTH", "PAR LA BOUCHE", -1)
 	line = strings.Replace(line, "ORALLY", "PAR LA BOUCHE", -1)
@@ -128,36 +149,27 @@ func Transform(line string) string {
 	line = strings.Replace(line, "INTO THE RECTUM", "DANS LE RECTUM", -1)
 	line = strings.Replace(line, "INTO THE LUNGS", "DANS LES POUMONS", -1)
 
-	re = regexp.MustCompile(`MAX(IMUM)? DAILY AMOUNT(:| IS)? (\d+) (MG|MCG|MEQ|ML|COMPRIMÉS|COMPRIMÉ|GOUTTES|GOUTTE|PASTILLES|UNITÉS|MCG|INHALATIONS|INHALATION|CAPSULE|BOUFFÉES|BOUFFÉE|APPLICATEUR|VAPORISATIONS|VAPORISATION|TIMBRE|APPLICATION|G|INHALATEURS|INHALATEUR|DOSES|DOSE)`)
-	line = re.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $3 $4)`)
+	line = maxDailyRe.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $3 $4)`)
 
-	re = regexp.MustCompile(`MAX(IMUM)? DAILY AMOUNT: (\d+)\.(\d+) (MG|MCG|ML|COMPRIMÉS|COMPRIMÉ)`)
-	line = re.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $2,$3 $4)`)
+	line = maxDailyDecimalRe.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $2,$3 $4)`)
 
-	re = regexp.MustCompile(`MAX DAILY AMOUNT: (\d+),(\d+) (MG|ML|COMPRIMÉS|COMPRIMÉ)`)
-	line = re.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $1$2 $3)`)
+	line = maxDailyThousandsRe.ReplaceAllString(line, `(DOSE MAX PAR JOUR: $1$2 $3)`)
 
 	line = strings.Replace(line, "DOSE IN 24 HOURS", "DOSE PAR JOUR", -1)
 	line = strings.Replace(line, "DOSES IN 24 HOURS", "DOSES PAR JOUR", -1)
 	line = strings.Replace(line, "MG IN 24 HOURS", "MG PAR JOUR", -1)
 
-	re = regexp.MustCompile(`(ONCE|ONE TIME) (DAILY|A DAY)`)
-	line = re.ReplaceAllString(line, `1 FOIS PAR JOUR`)
+	line = onceDailyRe.ReplaceAllString(line, `1 FOIS PAR JOUR`)
 
-	re = regexp.MustCompile(`(2|2 \(TWO\)|TWO) TIMES (DAILY|A DAY)`)
-	line = re.ReplaceAllString(line, `2 FOIS PAR JOUR`)
+	line = twoTimesDailyRe.ReplaceAllString(line, `2 FOIS PAR JOUR`)
 
-	re = regexp.MustCompile(`TWICE (DAILY|A DAY)`)
-	line = re.ReplaceAllString(line, `2 FOIS PAR JOUR`)
+	line = twiceDailyRe.ReplaceAllString(line, `2 FOIS PAR JOUR`)
 
-	re = regexp.MustCompile(`(3|3 \(THREE\)|THREE) TIMES (DAILY|A DAY)`)
-	line = re.ReplaceAllString(line, `3 FOIS PAR JOUR`)
+	line = threeTimesDailyRe.ReplaceAllString(line, `3 FOIS PAR JOUR`)
 
-	re = regexp.MustCompile(`(4|4 \(FOUR\)|FOUR) TIMES (DAILY|A DAY)`)
-	line = re.ReplaceAllString(line, `4 FOIS PAR JOUR`)
+	line = fourTimesDailyRe.ReplaceAllString(line, `4 FOIS PAR JOUR`)
 
-	re = regexp.MustCompile(`EVERY ([2-9]) DAYS`)
-	line = re.ReplaceAllString(line, `À TOUS LES $1 JOURS`)
+	line = everyFewDaysRe.ReplaceAllString(line, `À TOUS LES $1 JOURS`)
 
 	line = strings.Replace(line, "PRIOR TO FOOD", "À JEUN", -1)
 	line = strings.Replace(line, "BEFORE MEALS AND NIGHTLY", "AVANT LES REPAS ET AU COUCHER", -1)
@@ -175,8 +187,7 @@ func Transform(line string) string {
 	line = strings.Replace(line, "EVERY 4 (FOUR) TO 6 (SIX) HOURS", "AUX 4 À 6 HEURES", -1)
 	line = replaceFrequencies(line)
 
-	re = regexp.MustCompile(`EVERY (12|12 \(TWELVE\)|TWELVE) HOURS`)
-	line = re.ReplaceAllString(line, `AUX 12 HEURES`)
+	line = everyTwelveHoursRe.ReplaceAllString(line, `AUX 12 HEURES`)
 
 	line = strings.Replace(line, "ONCE A WEEK", "1 FOIS PAR SEMAINE", -1)
 	line = strings.Replace(line, "TWICE A WEEK", "2 FOIS PAR SEMAINE", -1)
@@ -198,11 +209,9 @@ func Transform(line string) string {
 	line = strings.Replace(line, "EVERY 3 (THREE) MONTHS", "AUX 3 MOIS", -1)
 	line = strings.Replace(line, "ONCE FOR 1 DOSE", "POUR 1 DOSE", -1)
 
-	re = regexp.MustCompile(`EVERY (\d+) DAYS`)
-	line = re.ReplaceAllString(line, `AUX $1 JOURS`)
+	line = everyDaysRe.ReplaceAllString(line, `AUX $1 JOURS`)
 
-	re = regexp.MustCompile(`IN (\d+) HOURS`)
-	line = re.ReplaceAllString(line, `DANS $1 HEURES`)
+	line = inHoursRe.ReplaceAllString(line, `DANS $1 HEURES`)
 
 	line = strings.Replace(line, "AS NEEDED", "AU BESOIN", -1)
 	line = strings.Replace(line, "IF NEEDED", "AU BESOIN", -1)
@@ -211,11 +220,9 @@ func Transform(line string) string {
 
 	line = strings.Replace(line, "FOR UP TO", "JUSQU'À UN MAXIMUM DE", -1)
 
-	re = regexp.MustCompile(`FOR (\d+) DAYS`)
-	line = re.ReplaceAllString(line, `POUR $1 JOURS`)
+	line = forDaysRe.ReplaceAllString(line, `POUR $1 JOURS`)
 
-	re = regexp.MustCompile(`X (\d+) DAYS`)
-	line = re.ReplaceAllString(line, `X $1 JOURS`)
+	line = timesDaysRe.ReplaceAllString(line, `X $1 JOURS`)
 
 	line = strings.Replace(line, "FOR MILD PAIN (1-3)", "POUR DOULEUR LÉGÈRE", -1)
 	line = strings.Replace(line, "FOR MODERATE PAIN (PAIN SCALE 4-7)", "POUR DOULEUR MODÉRÉE", -1)
@@ -262,17 +269,13 @@ func Transform(line string) string {
 	line = strings.Replace(line, "WITH A SMALL AMOUNT OF NON-DAIRY FOOD", "AVEC UN PEU DE NOURRITURE SANS PRODUITS LAITIERS", -1)
 	line = strings.Replace(line, "DON'T LIE DOWN", "ÉVITER DE S'ALLONGER", -1)
 
-	re = regexp.MustCompile(`^ONE\s`)
-	line = re.ReplaceAllString(line, `UN `)
+	line = oneStartRe.ReplaceAllString(line, `UN `)
 
-	re = regexp.MustCompile(`\sONE\s`)
-	line = re.ReplaceAllString(line, ` UN `)
+	line = oneRe.ReplaceAllString(line, ` UN `)
 
-	re = regexp.MustCompile(`\sTWO\s`)
-	line = re.ReplaceAllString(line, ` DEUX `)
+	line = twoRe.ReplaceAllString(line, ` DEUX `)
 
-	re = regexp.MustCompile(`^TWO\s`)
-	line = re.ReplaceAllString(line, `DEUX `)
+	line = twoStartRe.ReplaceAllString(line, `DEUX `)
 
 	line = strings.Replace(line, "TWO", "DEUX", -1)
 	line = strings.Replace(line, "THREE", "TROIS", -1)
@@ -296,35 +299,51 @@ func Transform(line string) string {
 	line = strings.Replace(line, " PO ", " PAR LA BOUCHE ", -1)
 	line = strings.Replace(line, " IN ", " DANS ", -1)
 
-	// Féminin
-	line = strings.Replace(line, "UN GOUTTE", "UNE GOUTTE", -1)
-	line = strings.Replace(line, "UN BOUFFÉE", "UNE BOUFFÉE", -1)
-	line = strings.Replace(line, "UN CAPSULE", "UNE CAPSULE", -1)
-	line = strings.Replace(line, "UN PILLULE", "UNE PILLULE", -1)
+	return line
+}
+
+// Noms féminins qui peuvent suivre l'article « UN » une fois ONE traduit
+var feminineNouns = []string{"GOUTTE", "BOUFFÉE", "CAPSULE", "PILULE", "PASTILLE", "NÉBULE", "APPLICATION", "INHALATION", "DOSE"}
+
+// FeminineAgreement accorde l'article avec les noms féminins (ex: UN GOUTTE → UNE GOUTTE)
+func FeminineAgreement(line string) string {
+	for _, noun := range feminineNouns {
+		line = strings.Replace(line, "UN "+noun, "UNE "+noun, -1)
+	}
+	return line
+}
 
-	// On remplace les points par des virgules (ex: 12.5 mg → 12,5 mg)
-	re = regexp.MustCompile(`([0-9])\.([0-9])`)
-	line = re.ReplaceAllString(line, `$1,$2`)
+// DecimalComma remplace les points décimaux par des virgules (ex: 12.5 MG → 12,5 MG)
+func DecimalComma(line string) string {
+	return decimalPointRe.ReplaceAllString(line, `$1,$2`)
+}
 
+func removeDoubleParentheses(line string) string {
 	line = strings.Replace(line, "((", "(", -1)
 	line = strings.Replace(line, "))", ")", -1)
-
 	return line
 }
 
 func replaceFrequencies(line string) string {
-	// hours
-	numbers := []string{"2", "3", "4", "6", "8", "12", "24"}
-	numbersInLetters := []string{"TWO", "THREE", "FOUR", "SIX", "EIGHT", "TWELVE", "TWENTYFOUR"}
-
-	for i, number := range numbers {
-		re := regexp.MustCompile(`EVERY ` + number + ` (\(` + numbersInLetters[i] + `\) )?HOURS`)
-		line = re.ReplaceAllString(line, `AUX `+number+` HEURES`)
+	for i, re := range everyHoursRes {
+		line = re.ReplaceAllString(line, `AUX `+hours[i]+` HEURES`)
 	}
 
 	return line
 }
 
+// hours
+var hours = []string{"2", "3", "4", "6", "8", "12", "24"}
+var hoursInLetters = []string{"TWO", "THREE", "FOUR", "SIX", "EIGHT", "TWELVE", "TWENTYFOUR"}
+
+func compileEveryHours() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for i, number := range hours {
+		res = append(res, regexp.MustCompile(`EVERY `+number+` (\(`+hoursInLetters[i]+`\) )?HOURS`))
+	}
+	return res
+}
+
 func replaceDecimalDoseWithComma(match string) string {
 	parts := strings.Split(match, " ")
 	number := strings.Replace(parts[0][1:], ".", ",", 1) //remove the opening bracket and replace the first dot with comma
@@ -1,4 +1,4 @@
-package old
+package translate
 
 import (
 	"testing"
@@ -250,3 +250,61 @@ func TestTransform(t *testing.T) {
 		})
 	}
 }
+
+func TestFeminineAgreement(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "INSTILLER UN GOUTTE DANS L'OEIL",
+			expected: "INSTILLER UNE GOUTTE DANS L'OEIL",
+		},
+		{
+			input:    "PRENDRE UN PILULE 2 FOIS PAR JOUR",
+			expected: "PRENDRE UNE PILULE 2 FOIS PAR JOUR",
+		},
+		{
+			input:    "DISSOUDRE UN PASTILLE DANS LA BOUCHE",
+			expected: "DISSOUDRE UNE PASTILLE DANS LA BOUCHE",
+		},
+		{
+			input:    "PRENDRE UN COMPRIMÉ",
+			expected: "PRENDRE UN COMPRIMÉ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual := FeminineAgreement(tc.input)
+			if actual != tc.expected {
+				t.Errorf("\nI: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecimalComma(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "PRENDRE 3 ML (1.25 MG)",
+			expected: "PRENDRE 3 ML (1,25 MG)",
+		},
+		{
+			input:    "PRENDRE 0.5 COMPRIMÉ. AVEC LES REPAS",
+			expected: "PRENDRE 0,5 COMPRIMÉ. AVEC LES REPAS",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual := DecimalComma(tc.input)
+			if actual != tc.expected {
+				t.Errorf("\nI: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
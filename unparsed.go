@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+// UnparsedReport regroupe les posologies dont un champ n'a pu être analysé,
+// pour repérer les formulations à ajouter aux règles
+type UnparsedReport struct {
+	Field    string    `json:"field"`
+	Total    int       `json:"total"`
+	Unparsed int       `json:"unparsed"`
+	Clusters []Cluster `json:"clusters"`
+	Ngrams   []Ngram   `json:"ngrams"`
+}
+
+// Cluster regroupe les posologies d'un même gabarit (ex: "PRENDRE # COMPRIME TOUS LES # MOIS")
+type Cluster struct {
+	Template string   `json:"template"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// Ngram est une suite de N mots et le nombre de posologies où elle apparaît
+type Ngram struct {
+	Text  string `json:"text"`
+	N     int    `json:"n"`
+	Count int    `json:"count"`
+}
+
+// Champs dont les posologies non analysées peuvent être regroupées
+var unparsedFields = map[string]func(poso.Dosage) bool{
+	"frequency": func(d poso.Dosage) bool { return d.Frequency == "" && len(d.Steps) == 0 },
+	"dose":      func(d poso.Dosage) bool { return d.Dose == "" && len(d.Steps) == 0 },
+	"route":     func(d poso.Dosage) bool { return d.Route == "" },
+	"all":       isUnparsed,
+}
+
+// Nombre d'exemples conservés par gabarit
+const clusterExamples = 3
+
+// Longueurs des n-grammes comptés
+const (
+	minNgram = 2
+	maxNgram = 4
+)
+
+var numberPattern = regexp.MustCompile(`[0-9]+(?:[.,/][0-9]+)*|[½¼¾]`)
+
+// UnparsedWriter regroupe les posologies non analysées par gabarit et compte
+// leurs n-grammes; le rapport est écrit à la fermeture, en texte ou en JSON
+type UnparsedWriter struct {
+	writer    io.Writer
+	format    string
+	field     string
+	unparsed  func(poso.Dosage) bool
+	top       int
+	total     int
+	templates map[string]*Cluster
+	ngrams    map[string]*Ngram
+}
+
+func NewUnparsedWriter(w io.Writer, format string, field string, top int) *UnparsedWriter {
+	return &UnparsedWriter{
+		writer:    w,
+		format:    format,
+		field:     field,
+		unparsed:  unparsedFields[field],
+		top:       top,
+		templates: map[string]*Cluster{},
+		ngrams:    map[string]*Ngram{},
+	}
+}
+
+func (u *UnparsedWriter) Write(dosage poso.Dosage) error {
+	u.total++
+	if !u.unparsed(dosage) {
+		return nil
+	}
+
+	template, err := Template(dosage.Text)
+	if err != nil {
+		return err
+	}
+
+	cluster, ok := u.templates[template]
+	if !ok {
+		cluster = &Cluster{Template: template}
+		u.templates[template] = cluster
+	}
+	cluster.Count++
+	if len(cluster.Examples) < clusterExamples {
+		cluster.Examples = append(cluster.Examples, dosage.Text)
+	}
+
+	// Chaque n-gramme est compté une seule fois par posologie
+	words := templateWords(template)
+	seen := map[string]bool{}
+	for n := minNgram; n <= maxNgram; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			ngram := words[i : i+n]
+			text := strings.Join(ngram, " ")
+			if seen[text] || !slices.ContainsFunc(ngram, func(word string) bool { return word != "#" }) {
+				continue
+			}
+			seen[text] = true
+
+			if _, ok := u.ngrams[text]; !ok {
+				u.ngrams[text] = &Ngram{Text: text, N: n}
+			}
+			u.ngrams[text].Count++
+		}
+	}
+	return nil
+}
+
+// Report retourne le rapport des posologies écrites jusqu'ici, limité aux top
+// gabarits et n-grammes les plus fréquents (tous si top vaut 0)
+func (u *UnparsedWriter) Report() UnparsedReport {
+	report := UnparsedReport{Field: u.field, Total: u.total, Clusters: []Cluster{}, Ngrams: []Ngram{}}
+
+	for _, cluster := range u.templates {
+		report.Unparsed += cluster.Count
+		report.Clusters = append(report.Clusters, *cluster)
+	}
+	slices.SortFunc(report.Clusters, func(a, b Cluster) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Template, b.Template)
+	})
+
+	// Un n-gramme vu une seule fois ne forme pas un motif
+	for _, ngram := range u.ngrams {
+		if ngram.Count > 1 {
+			report.Ngrams = append(report.Ngrams, *ngram)
+		}
+	}
+	slices.SortFunc(report.Ngrams, func(a, b Ngram) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		if a.N != b.N {
+			return b.N - a.N
+		}
+		return strings.Compare(a.Text, b.Text)
+	})
+
+	if u.top > 0 {
+		report.Clusters = report.Clusters[:min(u.top, len(report.Clusters))]
+		report.Ngrams = report.Ngrams[:min(u.top, len(report.Ngrams))]
+	}
+	return report
+}
+
+func (u *UnparsedWriter) Close() error {
+	report := u.Report()
+	if u.format == "json" {
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = u.writer.Write(append(jsonData, '\n'))
+		return err
+	}
+	return PrintUnparsedReport(u.writer, report)
+}
+
+// PrintUnparsedReport écrit le rapport en texte, en colonnes alignées
+func PrintUnparsedReport(w io.Writer, report UnparsedReport) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "Posologies non analysées (%s) : %d sur %d (%s)\n", report.Field, report.Unparsed, report.Total, formatPercent(percent(report.Unparsed, report.Total)))
+
+	fmt.Fprintf(writer, "\nGabarits les plus fréquents\n")
+	for _, cluster := range report.Clusters {
+		fmt.Fprintf(writer, "  %d\t%s\n", cluster.Count, cluster.Template)
+	}
+
+	fmt.Fprintf(writer, "\nN-grammes les plus fréquents\n")
+	for _, ngram := range report.Ngrams {
+		fmt.Fprintf(writer, "  %d\t%s\n", ngram.Count, ngram.Text)
+	}
+
+	return writer.Flush()
+}
+
+// Template retourne le gabarit d'une posologie : en majuscules, sans accents
+// (comme RemoveAccents), les nombres remplacés par # et les espaces réduits
+// (ex: "Prendre 1 comprimé tous les 6 mois" → "PRENDRE # COMPRIME TOUS LES # MOIS").
+func Template(text string) (string, error) {
+	template, err := poso.RemoveAccents(strings.ToUpper(text))
+	if err != nil {
+		return "", err
+	}
+	template = numberPattern.ReplaceAllString(template, "#")
+	return strings.Join(strings.Fields(template), " "), nil
+}
+
+// templateWords découpe un gabarit en mots, sans la ponctuation
+func templateWords(template string) []string {
+	return strings.FieldsFunc(template, func(r rune) bool {
+		return r != '#' && r != '\'' && !unicode.IsLetter(r)
+	})
+}
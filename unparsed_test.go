@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"raphaelcoutu/traduction-poso/poso"
+)
+
+func TestTemplate(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Prendre 1 comprimé tous les 6 mois", expected: "PRENDRE # COMPRIME TOUS LES # MOIS"},
+		{input: "PRENEZ ½ COMPRIME  UN JOUR SUR DEUX ", expected: "PRENEZ # COMPRIME UN JOUR SUR DEUX"},
+		{input: "Injecter 0,5 mL (12.5 mg) 1/2 heure avant", expected: "INJECTER # ML (# MG) # HEURE AVANT"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("TestTemplate", func(t *testing.T) {
+			actual, err := Template(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("I: %v\nE: %v\nA: %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnparsedWriter(t *testing.T) {
+	parser := poso.NewParser()
+	writer := NewUnparsedWriter(&bytes.Buffer{}, "text", "frequency", 0)
+	for _, line := range []string{
+		"PRENDRE 1 COMPRIME 1 FOIS PAR JOUR",
+		"Prendre 1 comprimé le lundi et le jeudi",
+		"PRENDRE 2 COMPRIMES LE LUNDI ET LE JEUDI",
+		"PRENDRE 1 COMPRIME LE LUNDI ET LE JEUDI",
+		"Appliquer 1 timbre chaque lundi",
+		"Appliquer 1 timbre chaque lundi (douleur)",
+	} {
+		dosage, err := parser.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Write(dosage); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := writer.Report()
+	if report.Total != 6 || report.Unparsed != 5 {
+		t.Errorf("E: %v sur %v\nA: %v sur %v", 5, 6, report.Unparsed, report.Total)
+	}
+
+	expected := Cluster{Template: "PRENDRE # COMPRIME LE LUNDI ET LE JEUDI", Count: 2, Examples: []string{"Prendre 1 comprimé le lundi et le jeudi", "PRENDRE 1 COMPRIME LE LUNDI ET LE JEUDI"}}
+	if !reflect.DeepEqual(report.Clusters[0], expected) {
+		t.Errorf("E: %+v\nA: %+v", expected, report.Clusters[0])
+	}
+
+	ngrams := map[string]int{}
+	for _, ngram := range report.Ngrams {
+		ngrams[ngram.Text] = ngram.Count
+	}
+	for text, count := range map[string]int{"LE LUNDI ET LE": 3, "TIMBRE CHAQUE LUNDI": 2, "APPLIQUER # TIMBRE": 2} {
+		if ngrams[text] != count {
+			t.Errorf("I: %v\nE: %v\nA: %v", text, count, ngrams[text])
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := PrintUnparsedReport(buffer, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "  3  LE LUNDI ET LE\n") {
+		t.Errorf("A: %s", buffer)
+	}
+}